@@ -0,0 +1,117 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/bbs"
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+// ErrReadOnly is returned by every action when dope runs with -read-only.
+var ErrReadOnly = errors.New("actions are disabled in read-only mode")
+
+// Action is a change to the BBS that is described before it runs, so that
+// the operator can confirm exactly which calls are made.
+type Action struct {
+	Description string
+	Calls       []string
+	Diff        []string
+
+	run func(bbs.Client) error
+}
+
+// RetireActualLRP stops an instance; the BBS starts a replacement if the
+// desired LRP still wants it. The BBS retires by index, which always picks
+// the instance that is not evacuating, so an evacuating instance cannot be
+// retired; it goes away on its own once its replacement runs.
+func RetireActualLRP(actualLRP *models.ActualLRP, evacuating bool) (*Action, error) {
+	key := actualLRP.ActualLRPKey
+	if evacuating {
+		return nil, fmt.Errorf("instance %d of %s is evacuating from %s, retiring it would stop its replacement instead",
+			key.Index, key.ProcessGuid, actualLRP.CellId)
+	}
+
+	return &Action{
+		Description: fmt.Sprintf("retire instance %d of %s on %s", key.Index, key.ProcessGuid, actualLRP.CellId),
+		Calls: []string{
+			fmt.Sprintf("RetireActualLRP(&models.ActualLRPKey{ProcessGuid: %q, Index: %d, Domain: %q})",
+				key.ProcessGuid, key.Index, key.Domain),
+		},
+		run: func(client bbs.Client) error {
+			return client.RetireActualLRP(&key)
+		},
+	}, nil
+}
+
+// CancelTask stops a pending or running task.
+func CancelTask(task *models.Task) (*Action, error) {
+	if task.State != models.Task_Pending && task.State != models.Task_Running {
+		return nil, fmt.Errorf("only pending or running tasks can be cancelled, %s is %s", task.TaskGuid, task.State)
+	}
+
+	taskGuid := task.TaskGuid
+	return &Action{
+		Description: fmt.Sprintf("cancel %s task %s", task.State, taskGuid),
+		Calls: []string{
+			fmt.Sprintf("CancelTask(%q)", taskGuid),
+		},
+		run: func(client bbs.Client) error {
+			return client.CancelTask(taskGuid)
+		},
+	}, nil
+}
+
+// ResolveTask deletes a completed task whose owner never resolved it, the
+// same way a task's owner does after handling its result.
+func ResolveTask(task *models.Task) (*Action, error) {
+	if task.State != models.Task_Completed && task.State != models.Task_Resolving {
+		return nil, fmt.Errorf("only completed tasks can be resolved, %s is %s", task.TaskGuid, task.State)
+	}
+
+	taskGuid := task.TaskGuid
+	resolving := task.State == models.Task_Resolving
+	calls := []string{
+		fmt.Sprintf("ResolvingTask(%q)", taskGuid),
+		fmt.Sprintf("DeleteTask(%q)", taskGuid),
+	}
+	if resolving {
+		// a previous attempt got as far as ResolvingTask
+		calls = calls[1:]
+	}
+
+	return &Action{
+		Description: fmt.Sprintf("resolve %s task %s", task.State, taskGuid),
+		Calls:       calls,
+		run: func(client bbs.Client) error {
+			if !resolving {
+				err := client.ResolvingTask(taskGuid)
+				if err != nil {
+					return err
+				}
+			}
+			return client.DeleteTask(taskGuid)
+		},
+	}, nil
+}
+
+// Runner runs confirmed actions against the BBS.
+type Runner struct {
+	client   bbs.Client
+	readOnly bool
+}
+
+func NewRunner(client bbs.Client, readOnly bool) *Runner {
+	return &Runner{client: client, readOnly: readOnly}
+}
+
+func (r *Runner) ReadOnly() bool {
+	return r.readOnly
+}
+
+func (r *Runner) Run(action *Action) error {
+	if r.readOnly {
+		return ErrReadOnly
+	}
+	return action.run(r.client)
+}
@@ -0,0 +1,147 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs"
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+// UpdateDesiredLRP changes the instances, annotation or routes of a desired
+// LRP. Diff shows the fields the update changes.
+func UpdateDesiredLRP(desired *models.DesiredLRP, update *models.DesiredLRPUpdate) (*Action, error) {
+	diff := []string{}
+	fields := []string{}
+
+	if update.Instances != nil && *update.Instances != desired.Instances {
+		diff = append(diff,
+			fmt.Sprintf("- instances: %d", desired.Instances),
+			fmt.Sprintf("+ instances: %d", *update.Instances),
+		)
+		fields = append(fields, fmt.Sprintf("Instances: &%d", *update.Instances))
+	}
+
+	if update.Annotation != nil && *update.Annotation != desired.Annotation {
+		diff = append(diff,
+			fmt.Sprintf("- annotation: %q", desired.Annotation),
+			fmt.Sprintf("+ annotation: %q", *update.Annotation),
+		)
+		fields = append(fields, fmt.Sprintf("Annotation: &%q", *update.Annotation))
+	}
+
+	if update.Routes != nil {
+		before, after := encodeRoutes(desired.Routes), encodeRoutes(update.Routes)
+		if before != after {
+			diff = append(diff,
+				"- routes: "+before,
+				"+ routes: "+after,
+			)
+			fields = append(fields, "Routes: &models.Routes"+after)
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, errors.New("nothing to change")
+	}
+
+	processGuid := desired.ProcessGuid
+	return &Action{
+		Description: "update desired LRP " + processGuid,
+		Calls: []string{
+			fmt.Sprintf("UpdateDesiredLRP(%q, &models.DesiredLRPUpdate{%s})", processGuid, strings.Join(fields, ", ")),
+		},
+		Diff: diff,
+		run: func(client bbs.Client) error {
+			return client.UpdateDesiredLRP(processGuid, update)
+		},
+	}, nil
+}
+
+func encodeRoutes(routes *models.Routes) string {
+	if routes == nil {
+		return "{}"
+	}
+	encoded, err := json.Marshal(routes)
+	if err != nil {
+		return err.Error()
+	}
+	return string(encoded)
+}
+
+// RouterHostnames returns the cf-router hostnames of routes.
+func RouterHostnames(routes *models.Routes) ([]string, error) {
+	cfRoutes, err := routerRoutes(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnames := []string{}
+	for _, route := range cfRoutes {
+		if encoded, ok := route["hostnames"]; ok {
+			routeHostnames := []string{}
+			err := json.Unmarshal(*encoded, &routeHostnames)
+			if err != nil {
+				return nil, err
+			}
+			hostnames = append(hostnames, routeHostnames...)
+		}
+	}
+	return hostnames, nil
+}
+
+// WithRouterHostnames returns a copy of routes with the cf-router hostnames
+// replaced, keeping the port and anything else the route holds. LRPs
+// routing more than one port are left alone since it is not clear which
+// port the hostnames belong to.
+func WithRouterHostnames(routes *models.Routes, hostnames []string) (*models.Routes, error) {
+	cfRoutes, err := routerRoutes(routes)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfRoutes) > 1 {
+		return nil, errors.New("the LRP routes more than one port, edit its routes with the cf cli instead")
+	}
+	if len(cfRoutes) == 0 {
+		return nil, errors.New("the LRP has no cf-router route to add hostnames to")
+	}
+
+	encodedHostnames, err := json.Marshal(hostnames)
+	if err != nil {
+		return nil, err
+	}
+	rawHostnames := json.RawMessage(encodedHostnames)
+	cfRoutes[0]["hostnames"] = &rawHostnames
+
+	encoded, err := json.Marshal(cfRoutes)
+	if err != nil {
+		return nil, err
+	}
+	rawRoutes := json.RawMessage(encoded)
+
+	updated := models.Routes{}
+	for key, value := range *routes {
+		updated[key] = value
+	}
+	updated["cf-router"] = &rawRoutes
+	return &updated, nil
+}
+
+// routerRoutes decodes the cf-router routes loosely so that fields dope
+// does not know about survive an edit.
+func routerRoutes(routes *models.Routes) ([]map[string]*json.RawMessage, error) {
+	cfRoutes := []map[string]*json.RawMessage{}
+	if routes == nil {
+		return cfRoutes, nil
+	}
+
+	encoded, ok := (*routes)["cf-router"]
+	if !ok || encoded == nil {
+		return cfRoutes, nil
+	}
+
+	err := json.Unmarshal(*encoded, &cfRoutes)
+	return cfRoutes, err
+}
@@ -0,0 +1,143 @@
+package api
+
+// indexHTML renders the same summary, cells and LRP tables as the terminal
+// UI from the /api/events stream.
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>dope</title>
+<style>
+  body { font-family: monospace; background: #1d1f21; color: #c5c8c6; margin: 1em; }
+  h2 { font-size: 1em; color: #f0c674; margin: 1.5em 0 0.5em; }
+  table { border-collapse: collapse; }
+  th { text-align: left; background: #373b41; padding: 2px 10px; }
+  td { padding: 2px 10px; }
+  tr.lrp td { font-weight: bold; padding-top: 8px; }
+  .UNCLAIMED { color: #c5c8c6; }
+  .CLAIMED { color: #f0c674; }
+  .RUNNING { color: #b5bd68; }
+  .CRASHED { color: #cc6666; }
+  .cpu { color: #b294bb; }
+  .memory { color: #8abeb7; }
+  .disk { color: #cc6666; }
+  #status { color: #969896; }
+</style>
+</head>
+<body>
+<div id="status">connecting...</div>
+<h2>Summary</h2>
+<div id="summary"></div>
+<h2>Cells</h2>
+<table id="cells"></table>
+<h2>LRPs</h2>
+<table id="lrps"></table>
+<script>
+function bytes(n) {
+  var units = ["B", "kB", "MB", "GB", "TB"];
+  var i = 0;
+  while (n >= 1000 && i < units.length - 1) { n /= 1000; i++; }
+  return (i === 0 ? n : n.toFixed(1)) + units[i];
+}
+
+// free is the capacity left after reservations, unknown for cells the BBS
+// has no presence for.
+function free(capacity, reserved) {
+  if (!capacity) { return "?"; }
+  return bytes(Math.max(capacity - reserved, 0));
+}
+
+function percent(ratio) {
+  return (ratio * 100).toFixed(1) + "%";
+}
+
+function cell(text, cls) {
+  var td = document.createElement("td");
+  td.textContent = text;
+  if (cls) { td.className = cls; }
+  return td;
+}
+
+function row(table, cells, cls) {
+  var tr = document.createElement("tr");
+  if (cls) { tr.className = cls; }
+  cells.forEach(function(td) { tr.appendChild(td); });
+  table.appendChild(tr);
+}
+
+function header(table, names) {
+  var tr = document.createElement("tr");
+  names.forEach(function(name) {
+    var th = document.createElement("th");
+    th.textContent = name;
+    tr.appendChild(th);
+  });
+  table.appendChild(tr);
+}
+
+function render(snapshot) {
+  var total = { cpu: 0, memoryUsed: 0, memoryReserved: 0, diskUsed: 0, diskReserved: 0, memoryCapacity: 0, diskCapacity: 0, lrps: 0, tasks: 0 };
+  var cells = document.getElementById("cells");
+  cells.innerHTML = "";
+  header(cells, ["cell", "zone", "LRPs", "tasks", "cpu", "memory", "disk", "free memory", "free disk"]);
+  snapshot.cells.forEach(function(c) {
+    total.cpu += c.cpu;
+    total.memoryUsed += c.memory_used_bytes;
+    total.memoryReserved += c.memory_reserved_bytes;
+    total.diskUsed += c.disk_used_bytes;
+    total.diskReserved += c.disk_reserved_bytes;
+    total.memoryCapacity += c.memory_capacity_bytes;
+    total.diskCapacity += c.disk_capacity_bytes;
+    total.lrps += c.num_lrps;
+    total.tasks += c.num_tasks;
+    row(cells, [
+      cell(c.cell_id), cell(c.zone), cell(c.num_lrps), cell(c.num_tasks),
+      cell(percent(c.cpu), "cpu"),
+      cell(bytes(c.memory_used_bytes) + "/" + bytes(c.memory_reserved_bytes), "memory"),
+      cell(bytes(c.disk_used_bytes) + "/" + bytes(c.disk_reserved_bytes), "disk"),
+      cell(free(c.memory_capacity_bytes, c.memory_reserved_bytes), "memory"),
+      cell(free(c.disk_capacity_bytes, c.disk_reserved_bytes), "disk")
+    ]);
+  });
+
+  var averageCPU = snapshot.cells.length ? total.cpu / snapshot.cells.length : 0;
+  document.getElementById("summary").textContent =
+    "Cells: " + snapshot.cells.length +
+    "  LRPs: " + total.lrps +
+    "  Tasks: " + total.tasks +
+    "  Average CPU: " + percent(averageCPU) +
+    "  Total Memory: " + bytes(total.memoryUsed) + "/" + bytes(total.memoryReserved) +
+    "  Total Disk: " + bytes(total.diskUsed) + "/" + bytes(total.diskReserved) +
+    "  Free Memory: " + free(total.memoryCapacity, total.memoryReserved) +
+    "  Free Disk: " + free(total.diskCapacity, total.diskReserved);
+
+  var lrps = document.getElementById("lrps");
+  lrps.innerHTML = "";
+  header(lrps, ["guid", "instances", "index", "cell", "state", "cpu", "memory/total", "disk/total"]);
+  snapshot.lrps.forEach(function(lrp) {
+    row(lrps, [cell(lrp.process_guid), cell(lrp.instances), cell(""), cell(""), cell(""), cell(""), cell(""), cell("")], "lrp");
+    var memoryLimit = lrp.memory_mb * 1024 * 1024;
+    var diskLimit = lrp.disk_mb * 1024 * 1024;
+    lrp.actuals.forEach(function(a) {
+      row(lrps, [
+        cell(""), cell(""), cell(a.index), cell(a.cell_id),
+        cell(a.state, a.state),
+        cell(percent(a.metrics.cpu), "cpu"),
+        cell(bytes(a.metrics.memory_bytes) + "/" + bytes(memoryLimit), "memory"),
+        cell(bytes(a.metrics.disk_bytes) + "/" + bytes(diskLimit), "disk")
+      ]);
+    });
+  });
+
+  document.getElementById("status").textContent = "updated " + new Date(snapshot.timestamp).toLocaleString();
+}
+
+var events = new EventSource("/api/events");
+events.addEventListener("snapshot", function(e) { render(JSON.parse(e.data)); });
+events.onerror = function() {
+  document.getElementById("status").textContent = "disconnected, retrying...";
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luan/dope/export"
+	"github.com/luan/dope/fetcher"
+)
+
+// Server serves the latest snapshot as JSON, pushes every update to
+// /api/events subscribers and hosts a small dashboard at /.
+//
+//	GET /api/snapshot
+//	GET /api/lrps
+//	GET /api/lrps/:process_guid
+//	GET /api/cells
+//	GET /api/tasks
+//	GET /api/domains
+//	GET /api/events
+type Server struct {
+	mux *http.ServeMux
+
+	lock        sync.Mutex
+	snapshot    *export.Snapshot
+	encoded     []byte
+	subscribers map[chan []byte]struct{}
+}
+
+func NewServer() *Server {
+	s := &Server{
+		mux:         http.NewServeMux(),
+		subscribers: map[chan []byte]struct{}{},
+	}
+
+	s.mux.HandleFunc("/", s.index)
+	s.mux.HandleFunc("/api/snapshot", s.handleSnapshot)
+	s.mux.HandleFunc("/api/lrps", s.lrps)
+	s.mux.HandleFunc("/api/lrps/", s.lrp)
+	s.mux.HandleFunc("/api/cells", s.cells)
+	s.mux.HandleFunc("/api/tasks", s.tasks)
+	s.mux.HandleFunc("/api/domains", s.domains)
+	s.mux.HandleFunc("/api/events", s.events)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetState publishes a new snapshot to every request from now on and to
+// every open event stream.
+func (s *Server) SetState(data *fetcher.Data) {
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	snapshot := export.NewSnapshot(data, timestamp)
+
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.snapshot = &snapshot
+	s.encoded = encoded
+	for subscriber := range s.subscribers {
+		// slow subscribers only get the latest snapshot
+		select {
+		case <-subscriber:
+		default:
+		}
+		subscriber <- encoded
+	}
+}
+
+func (s *Server) current() *export.Snapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snapshot
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		return snapshot
+	})
+}
+
+func (s *Server) lrps(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		return snapshot.LRPs
+	})
+}
+
+func (s *Server) lrp(w http.ResponseWriter, r *http.Request) {
+	processGuid := strings.TrimPrefix(r.URL.Path, "/api/lrps/")
+
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		for _, lrp := range snapshot.LRPs {
+			if lrp.ProcessGuid == processGuid {
+				return lrp
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Server) cells(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		return snapshot.Cells
+	})
+}
+
+func (s *Server) tasks(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		return snapshot.Tasks
+	})
+}
+
+func (s *Server) domains(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, func(snapshot *export.Snapshot) interface{} {
+		return snapshot.Domains
+	})
+}
+
+// respond writes whatever body picks out of the current snapshot, 503 if
+// nothing was fetched yet and 404 if body returns nil.
+func (s *Server) respond(w http.ResponseWriter, body func(*export.Snapshot) interface{}) {
+	snapshot := s.current()
+	if snapshot == nil {
+		writeError(w, http.StatusServiceUnavailable, "no data fetched yet")
+		return
+	}
+
+	value := body(snapshot)
+	if value == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	subscriber := make(chan []byte, 1)
+	s.lock.Lock()
+	s.subscribers[subscriber] = struct{}{}
+	if s.encoded != nil {
+		subscriber <- s.encoded
+	}
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.subscribers, subscriber)
+		s.lock.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case encoded := <-subscriber:
+			_, err := fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", encoded)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	encoded, _ := json.Marshal(map[string]string{"error": message})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encoded)
+}
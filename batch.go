@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/luan/dope/fetcher"
+	"github.com/luan/dope/filter"
+)
+
+// runBatch prints plain text snapshots, similar to `top -b`. An iterations
+// value of zero keeps printing until the process is killed.
+func runBatch(f fetcher.Fetcher, lrpFilter *filter.Filter, iterations int, delay time.Duration, w io.Writer) error {
+	for i := 0; iterations == 0 || i < iterations; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			fmt.Fprintln(w)
+		}
+
+		state, err := f.Fetch()
+		if fetchErr, ok := err.(*fetcher.FetchError); ok && fetchErr.LRPs == nil {
+			// still print the LRPs, only tasks or domains are missing
+			fmt.Fprintln(os.Stderr, err)
+		} else if err != nil {
+			return err
+		}
+
+		printBatch(w, &state, lrpFilter, time.Now())
+	}
+
+	return nil
+}
+
+func printBatch(w io.Writer, state *fetcher.Data, lrpFilter *filter.Filter, now time.Time) {
+	cellStates := state.GetCellState()
+	cells := cellStates.SortedByCellId()
+	total := cellStates.Total()
+
+	averageCPU := 0.0
+	if len(cells) > 0 {
+		averageCPU = float64(100) * total.CPUPercentage / float64(len(cells))
+	}
+
+	fmt.Fprintf(w, "dope - %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(w,
+		"Cells: %d, LRPs: %d, Tasks: %d, Average CPU: %.1f%%, Total Memory: %s/%s, Total Disk: %s/%s\n",
+		len(cells), total.NumLRPs, total.NumTasks, averageCPU,
+		fmtBytes(total.MemoryUsed), fmtBytes(total.MemoryReserved),
+		fmtBytes(total.DiskUsed), fmtBytes(total.DiskReserved),
+	)
+	fmt.Fprintf(w,
+		"Capacity: Memory: %s (%s free), Disk: %s (%s free), Containers: %d (%d free)\n",
+		fmtCapacity(total.MemoryCapacity, total.MemoryCapacity), fmtCapacity(total.MemoryFree(), total.MemoryCapacity),
+		fmtCapacity(total.DiskCapacity, total.DiskCapacity), fmtCapacity(total.DiskFree(), total.DiskCapacity),
+		total.ContainerCapacity, total.ContainersFree(),
+	)
+	fmt.Fprintf(w, "Domains: %s\n", strings.Join(state.Domains, ", "))
+	for _, domain := range missingDomains(state.Domains) {
+		fmt.Fprintf(w, "WARNING: domain %s is not fresh, the BBS will not converge its LRPs\n", domain)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CELL\tZONE\tLRPS\tTASKS\tCPU\tMEMORY\tDISK\tFREE MEMORY\tFREE DISK")
+	for _, cell := range cells {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f%%\t%s/%s\t%s/%s\t%s\t%s\n",
+			cell.CellId, cell.Zone, cell.NumLRPs, cell.NumTasks,
+			float64(100)*cell.CPUPercentage,
+			fmtBytes(cell.MemoryUsed), fmtBytes(cell.MemoryReserved),
+			fmtBytes(cell.DiskUsed), fmtBytes(cell.DiskReserved),
+			fmtCapacity(cell.MemoryFree(), cell.MemoryCapacity), fmtCapacity(cell.DiskFree(), cell.DiskCapacity),
+		)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ZONE\tCELLS\tLRPS\tTASKS\tAVERAGE CPU\tMEMORY\tDISK\tFREE MEMORY\tFREE DISK")
+	for _, zone := range cellStates.ByZone().SortedByZone() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%s/%s\t%s/%s\t%s\t%s\n",
+			fmtZone(zone.Zone), zone.NumCells, zone.NumLRPs, zone.NumTasks,
+			float64(100)*zone.AverageCPU(),
+			fmtBytes(zone.MemoryUsed), fmtBytes(zone.MemoryReserved),
+			fmtBytes(zone.DiskUsed), fmtBytes(zone.DiskReserved),
+			fmtCapacity(zone.MemoryFree(), zone.MemoryCapacity), fmtCapacity(zone.DiskFree(), zone.DiskCapacity),
+		)
+	}
+	tw.Flush()
+	placement := state.Placement()
+	for _, lrp := range placement.SingleZoneLRPs(lrpFilter.Apply(state.LRPs)) {
+		fmt.Fprintf(w, "WARNING: every instance of %s is in one zone (%s)\n",
+			lrp.Desired.ProcessGuid, fmtDistribution(placement.Distribution(lrp)))
+	}
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROCESS GUID\tINSTANCES\tINDEX\tCELL\tSTATE\tCPU\tMEMORY\tDISK")
+	for _, lrp := range lrpFilter.Apply(state.LRPs).SortedByProcessGuid() {
+		memoryLimit := fmtBytes(fetcher.Megabytes(lrp.Desired.MemoryMb))
+		diskLimit := fmtBytes(fetcher.Megabytes(lrp.Desired.DiskMb))
+
+		actuals := lrp.ActualLRPsByIndex(false)
+		if len(actuals) == 0 {
+			fmt.Fprintf(tw, "%s\t%d\t-\t-\t-\t-\t-/%s\t-/%s\n",
+				lrp.Desired.ProcessGuid, lrp.Desired.Instances, memoryLimit, diskLimit)
+		}
+
+		for _, actual := range actuals {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%.1f%%\t%s/%s\t%s/%s\n",
+				lrp.Desired.ProcessGuid, lrp.Desired.Instances,
+				actual.ActualLRP.Index, fmtCell(actual.ActualLRP.CellId), batchState(actual),
+				actual.Metrics.CPU*100,
+				fmtBytes(actual.Metrics.Memory), memoryLimit,
+				fmtBytes(actual.Metrics.Disk), diskLimit,
+			)
+		}
+	}
+	tw.Flush()
+
+	orphans := lrpFilter.ApplyOrphans(state.Orphans).SortedByProcessGuid()
+	if len(orphans) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Orphaned actual LRPs (no desired LRP):")
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROCESS GUID\tINDEX\tCELL\tSTATE\tCRASHES")
+	for _, actual := range orphans {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\n",
+			actual.ActualLRP.ProcessGuid, actual.ActualLRP.Index,
+			fmtCell(actual.ActualLRP.CellId), batchState(actual),
+			actual.ActualLRP.CrashCount,
+		)
+	}
+	tw.Flush()
+}
+
+// batchState marks the evacuating half of an evacuation, which shares its
+// index with the replacement.
+func batchState(actual *fetcher.Actual) string {
+	if actual.Evacuating {
+		return actual.ActualLRP.State + " (evacuating)"
+	}
+	return actual.ActualLRP.State
+}
+
+func missingDomains(domains []string) []string {
+	fresh := map[string]bool{}
+	for _, domain := range domains {
+		fresh[domain] = true
+	}
+
+	missing := []string{}
+	for _, domain := range fetcher.WellKnownDomains {
+		if !fresh[domain] {
+			missing = append(missing, domain)
+		}
+	}
+	return missing
+}
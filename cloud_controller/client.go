@@ -0,0 +1,191 @@
+package cloud_controller
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// App holds the names the Cloud Controller knows an LRP by.
+type App struct {
+	Guid  string
+	Name  string
+	Space string
+	Org   string
+}
+
+// Client caches the names of every app, space and org and refreshes them
+// periodically. A nil client knows no names.
+type Client struct {
+	url        string
+	token      func() (string, error)
+	httpClient *http.Client
+
+	lock sync.Mutex
+	apps map[string]App
+}
+
+func NewClient(url string, token func() (string, error), tlsConfig *tls.Config) *Client {
+	return &Client{
+		url:   strings.TrimSuffix(url, "/"),
+		token: token,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		apps: map[string]App{},
+	}
+}
+
+// AppGuid is the app guid part of the process guid of a CF app, which is
+// the app guid followed by the guid of the app version.
+func AppGuid(processGuid string) string {
+	const guidLength = 36
+	if len(processGuid) < guidLength {
+		return processGuid
+	}
+	return processGuid[:guidLength]
+}
+
+// Lookup returns the names of the app an LRP runs, if it is a CF app.
+func (c *Client) Lookup(processGuid string) (App, bool) {
+	if c == nil {
+		return App{}, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	app, ok := c.apps[AppGuid(processGuid)]
+	return app, ok
+}
+
+// Run refreshes the names every interval, forever. errorHandler is called
+// with every failure and with nil once refreshing succeeds again.
+func (c *Client) Run(interval time.Duration, errorHandler func(error)) {
+	failing := false
+	for {
+		err := c.Refresh()
+		if err != nil || failing {
+			errorHandler(err)
+		}
+		failing = err != nil
+		time.Sleep(interval)
+	}
+}
+
+// Refresh fetches every org, space and app. The previous names are kept if
+// anything fails.
+func (c *Client) Refresh() error {
+	orgs := map[string]string{}
+	err := c.list("/v2/organizations", func(guid string, entity json.RawMessage) error {
+		org := struct {
+			Name string `json:"name"`
+		}{}
+		err := json.Unmarshal(entity, &org)
+		orgs[guid] = org.Name
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	type space struct {
+		Name    string `json:"name"`
+		OrgGuid string `json:"organization_guid"`
+	}
+	spaces := map[string]space{}
+	err = c.list("/v2/spaces", func(guid string, entity json.RawMessage) error {
+		s := space{}
+		err := json.Unmarshal(entity, &s)
+		spaces[guid] = s
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	apps := map[string]App{}
+	err = c.list("/v2/apps", func(guid string, entity json.RawMessage) error {
+		app := struct {
+			Name      string `json:"name"`
+			SpaceGuid string `json:"space_guid"`
+		}{}
+		err := json.Unmarshal(entity, &app)
+		s := spaces[app.SpaceGuid]
+		apps[guid] = App{
+			Guid:  guid,
+			Name:  app.Name,
+			Space: s.Name,
+			Org:   orgs[s.OrgGuid],
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.apps = apps
+	c.lock.Unlock()
+	return nil
+}
+
+// list calls resource with every resource of every page of path.
+func (c *Client) list(path string, resource func(guid string, entity json.RawMessage) error) error {
+	next := path + "?results-per-page=100"
+	for next != "" {
+		page := struct {
+			NextURL   string `json:"next_url"`
+			Resources []struct {
+				Metadata struct {
+					Guid string `json:"guid"`
+				} `json:"metadata"`
+				Entity json.RawMessage `json:"entity"`
+			} `json:"resources"`
+		}{}
+
+		err := c.get(next, &page)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range page.Resources {
+			err := resource(r.Metadata.Guid, r.Entity)
+			if err != nil {
+				return err
+			}
+		}
+		next = page.NextURL
+	}
+	return nil
+}
+
+func (c *Client) get(path string, body interface{}) error {
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("GET", c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", token)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(body)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/luan/dope/config_finder"
+)
+
+// loadConfig layers flags over the environment over the selected profile
+// of the config file.
+func loadConfig() (config_finder.Config, error) {
+	config := config_finder.Config{
+		BBS: config_finder.BBSConfig{
+			URL:        *bbsURL,
+			CertFile:   *bbsCertFile,
+			KeyFile:    *bbsKeyFile,
+			CAFile:     *bbsCAFile,
+			ServerName: *bbsServerName,
+			SkipVerify: *insecureSkipVerify,
+		},
+		Noaa: config_finder.NoaaConfig{
+			TrafficControllerURL: *trafficControllerURL,
+			CAFile:               *trafficControllerCA,
+			ServerName:           *trafficControllerSNI,
+			SkipVerify:           *insecureSkipVerify,
+		},
+		UAA: config_finder.UAAConfig{
+			CAFile:     *uaaCAFile,
+			SkipVerify: *insecureSkipVerify,
+		},
+		CloudController: config_finder.CloudControllerConfig{
+			URL:        *cloudControllerURL,
+			CAFile:     *cloudControllerCA,
+			SkipVerify: *insecureSkipVerify,
+		},
+		Filter:   *filterExpression,
+		ReadOnly: *readOnly,
+	}
+	config.PopulateFromEnv()
+
+	configFile, err := config_finder.LoadConfigFile(*configPath)
+	if err != nil {
+		return config, err
+	}
+	profile, err := configFile.Profile(*profileName)
+	if err != nil {
+		return config, err
+	}
+	config.PopulateFromProfile(profile)
+
+	if isFlagSet("read-only") {
+		config.ReadOnly = *readOnly
+	}
+	if isFlagSet("insecure-skip-verify") {
+		config.BBS.SkipVerify = *insecureSkipVerify
+		config.Noaa.SkipVerify = *insecureSkipVerify
+		config.UAA.SkipVerify = *insecureSkipVerify
+		config.CloudController.SkipVerify = *insecureSkipVerify
+	}
+	return config, nil
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
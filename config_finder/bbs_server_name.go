@@ -0,0 +1,153 @@
+package config_finder
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"time"
+)
+
+// serveWithServerName lets the BBS client, which builds its own TLS config,
+// reach a BBS whose certificate names something other than the host of its
+// URL. It serves on loopback with a throwaway certificate, only to clients
+// presenting dope's own client certificate, and forwards every request to
+// the BBS over TLS checked against the server name. It returns the URL to
+// point the BBS client at and a CA file that verifies it, which the caller
+// removes once the client has loaded it. Failures to reach the BBS are sent
+// to c.ProxyErrors, the BBS client only sees them as bad responses.
+func serveWithServerName(c BBSConfig) (string, string, error) {
+	target, err := url.Parse(c.URL)
+	if err != nil {
+		return "", "", err
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	upstreamTLS, err := newTLSConfig(c.CAFile, c.ServerName, c.SkipVerify)
+	if err != nil {
+		return "", "", err
+	}
+	upstreamTLS.Certificates = []tls.Certificate{clientCert}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: upstreamTLS,
+	}
+	// events are streamed
+	proxy.FlushInterval = 100 * time.Millisecond
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		c.reportProxyError(err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	// logging would draw over the UI
+	errorLog := log.New(ioutil.Discard, "", 0)
+	proxy.ErrorLog = errorLog
+
+	loopbackCert, caFile, err := newLoopbackCertificate()
+	if err != nil {
+		return "", "", err
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{loopbackCert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})
+	if err != nil {
+		os.Remove(caFile)
+		return "", "", err
+	}
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peers := r.TLS.PeerCertificates
+			if len(peers) == 0 || !bytes.Equal(peers[0].Raw, clientCert.Certificate[0]) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			proxy.ServeHTTP(w, r)
+		}),
+		ErrorLog: errorLog,
+	}
+	go func() {
+		c.reportProxyError(server.Serve(listener))
+	}()
+
+	return "https://" + listener.Addr().String(), caFile, nil
+}
+
+// newLoopbackCertificate makes a self-signed certificate for 127.0.0.1 and
+// writes it to a temporary file the BBS client trusts. It can only serve
+// TLS, not sign other certificates.
+func newLoopbackCertificate() (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "dope bbs proxy"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+
+	caFile, err := ioutil.TempFile("", "dope-bbs-ca")
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if closeErr := caFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(caFile.Name())
+		return tls.Certificate{}, "", err
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return cert, caFile.Name(), nil
+}
+
+// reportProxyError passes err on without blocking the proxy, dropping it if
+// nobody is listening or an earlier error is still unread.
+func (c BBSConfig) reportProxyError(err error) {
+	if c.ProxyErrors == nil {
+		return
+	}
+	select {
+	case c.ProxyErrors <- fmt.Errorf("reaching the BBS as %s: %s", c.ServerName, err):
+	default:
+	}
+}
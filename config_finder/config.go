@@ -0,0 +1,76 @@
+package config_finder
+
+import (
+	"os"
+	"strings"
+)
+
+// Config is everything dope reads from flags, the environment and a
+// profile of the config file. Each source only fills in what the ones
+// before it left empty, so populate from the highest precedence first.
+type Config struct {
+	BBS             BBSConfig
+	Noaa            NoaaConfig
+	UAA             UAAConfig
+	CloudController CloudControllerConfig
+	OAuthToken      string
+
+	Filter   string
+	ReadOnly bool
+}
+
+func (c *Config) PopulateFromEnv() {
+	c.BBS.PopulateFromEnv()
+	c.Noaa.PopulateFromEnv()
+	c.UAA.PopulateFromEnv()
+	c.CloudController.PopulateFromEnv()
+	if c.OAuthToken == "" {
+		c.OAuthToken = os.Getenv("OAUTH_TOKEN")
+	}
+}
+
+func (c *Config) PopulateFromProfile(p Profile) {
+	c.BBS.PopulateFromProfile(p)
+	c.Noaa.PopulateFromProfile(p)
+	c.UAA.PopulateFromProfile(p)
+	c.CloudController.PopulateFromProfile(p)
+	if c.OAuthToken == "" {
+		c.OAuthToken = p.OAuthToken
+	}
+	if c.Filter == "" {
+		c.Filter = p.Filter
+	}
+	c.ReadOnly = c.ReadOnly || p.ReadOnly
+	c.BBS.SkipVerify = c.BBS.SkipVerify || p.InsecureSkipVerify
+	c.Noaa.SkipVerify = c.Noaa.SkipVerify || p.InsecureSkipVerify
+	c.UAA.SkipVerify = c.UAA.SkipVerify || p.InsecureSkipVerify
+	c.CloudController.SkipVerify = c.CloudController.SkipVerify || p.InsecureSkipVerify
+}
+
+// InsecureWarning describes which connections skip certificate
+// verification, if any.
+func (c *Config) InsecureWarning() string {
+	insecure := []string{}
+	if c.BBS.SkipVerify {
+		insecure = append(insecure, "the BBS")
+	}
+	if c.Noaa.SkipVerify {
+		insecure = append(insecure, "the traffic controller")
+	}
+	if c.UAA.SkipVerify {
+		insecure = append(insecure, "the UAA")
+	}
+	if c.CloudController.URL != "" && c.CloudController.SkipVerify {
+		insecure = append(insecure, "the Cloud Controller")
+	}
+
+	switch len(insecure) {
+	case 0:
+		return ""
+	case 1:
+		return "TLS certificate of " + insecure[0] + " is not verified"
+	default:
+		last := len(insecure) - 1
+		return "TLS certificates of " + strings.Join(insecure[:last], ", ") + " and " + insecure[last] + " are not verified"
+	}
+}
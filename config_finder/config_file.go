@@ -0,0 +1,133 @@
+package config_finder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile holds a profile per foundation:
+//
+//	default: staging
+//	profiles:
+//	  staging:
+//	    bbs_url: https://bbs.service.cf.internal:8889
+//	    bbs_cert_file: ~/certs/staging/client.crt
+//	    bbs_key_file: ~/certs/staging/client.key
+//	    bbs_ca_file: ~/certs/staging/ca.crt
+//	    bbs_server_name: bbs.service.cf.internal
+//	    traffic_controller_url: wss://doppler.staging.example.com:443
+//	    traffic_controller_ca_file: ~/certs/staging/ca.crt
+//	    uaa_url: https://uaa.staging.example.com
+//	    uaa_client_id: dope
+//	    uaa_client_secret: ...
+//	    uaa_ca_file: ~/certs/staging/ca.crt
+//	    cloud_controller_url: https://api.staging.example.com
+//	    cloud_controller_ca_file: ~/certs/staging/ca.crt
+//	    filter: domain:cf-apps
+//	    read_only: true
+type ConfigFile struct {
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	BBSURL        string `yaml:"bbs_url"`
+	BBSCertFile   string `yaml:"bbs_cert_file"`
+	BBSKeyFile    string `yaml:"bbs_key_file"`
+	BBSCAFile     string `yaml:"bbs_ca_file"`
+	BBSServerName string `yaml:"bbs_server_name"`
+
+	TrafficControllerURL        string `yaml:"traffic_controller_url"`
+	TrafficControllerCAFile     string `yaml:"traffic_controller_ca_file"`
+	TrafficControllerServerName string `yaml:"traffic_controller_server_name"`
+	OAuthToken                  string `yaml:"oauth_token"`
+
+	// UAA is used for tokens when no OAuth token is set, with the refresh
+	// token if there is one and client credentials otherwise.
+	UAAURL          string `yaml:"uaa_url"`
+	UAAClientID     string `yaml:"uaa_client_id"`
+	UAAClientSecret string `yaml:"uaa_client_secret"`
+	UAARefreshToken string `yaml:"uaa_refresh_token"`
+	UAACAFile       string `yaml:"uaa_ca_file"`
+
+	CloudControllerURL    string `yaml:"cloud_controller_url"`
+	CloudControllerCAFile string `yaml:"cloud_controller_ca_file"`
+
+	// InsecureSkipVerify turns off certificate verification for every
+	// endpoint.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+
+	Filter   string `yaml:"filter"`
+	ReadOnly bool   `yaml:"read_only"`
+}
+
+// DefaultConfigPath is ~/.dope.yml.
+func DefaultConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".dope.yml")
+}
+
+// LoadConfigFile reads the config file at path. A missing file is the same
+// as an empty one.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	configFile := &ConfigFile{}
+
+	contents, err := ioutil.ReadFile(expandHome(path))
+	if os.IsNotExist(err) {
+		return configFile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(contents, configFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	return configFile, nil
+}
+
+// Profile returns the named profile, or the default one if name is empty.
+// Paths in the profile may start with ~.
+func (c *ConfigFile) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("no profile %q in the config file, expected one of: %s", name, strings.Join(c.profileNames(), ", "))
+	}
+
+	profile.BBSCertFile = expandHome(profile.BBSCertFile)
+	profile.BBSKeyFile = expandHome(profile.BBSKeyFile)
+	profile.BBSCAFile = expandHome(profile.BBSCAFile)
+	profile.TrafficControllerCAFile = expandHome(profile.TrafficControllerCAFile)
+	profile.UAACAFile = expandHome(profile.UAACAFile)
+	profile.CloudControllerCAFile = expandHome(profile.CloudControllerCAFile)
+	return profile, nil
+}
+
+func (c *ConfigFile) profileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
@@ -12,18 +12,36 @@ type BBSConfig struct {
 	URL      string
 	CertFile string
 	KeyFile  string
+	// CAFile verifies the BBS certificate, the system roots are used if
+	// it is empty.
+	CAFile string
+	// ServerName is checked against the certificate instead of the host of
+	// the URL.
+	ServerName string
+	SkipVerify bool
+	// ProxyErrors is sent failures to reach the BBS when ServerName is
+	// set, see serveWithServerName.
+	ProxyErrors chan<- error
 }
 
-func NewBBS() (bbs.Client, error) {
-	c := BBSConfig{}
-	c.PopulateFromEnv()
+func NewBBS(c BBSConfig) (bbs.Client, error) {
 	err := c.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	if c.IsSecure() {
+	if c.IsSecure() && c.SkipVerify {
 		return bbs.NewSecureSkipVerifyClient(c.URL, c.CertFile, c.KeyFile, 0, 0)
+	} else if c.IsSecure() && c.ServerName != "" {
+		proxyURL, caFile, err := serveWithServerName(c)
+		if err != nil {
+			return nil, err
+		}
+		// the client reads the CA file as it is made
+		defer os.Remove(caFile)
+		return bbs.NewSecureClient(proxyURL, caFile, c.CertFile, c.KeyFile, 0, 0)
+	} else if c.IsSecure() {
+		return bbs.NewSecureClient(c.URL, c.CAFile, c.CertFile, c.KeyFile, 0, 0)
 	} else {
 		return bbs.NewClient(c.URL), nil
 	}
@@ -47,18 +65,42 @@ func (c *BBSConfig) PopulateFromEnv() {
 	if c.KeyFile == "" {
 		c.KeyFile = os.Getenv("BBS_KEY_FILE")
 	}
+	if c.CAFile == "" {
+		c.CAFile = os.Getenv("BBS_CA_FILE")
+	}
+	if c.ServerName == "" {
+		c.ServerName = os.Getenv("BBS_SERVER_NAME")
+	}
+}
+
+func (c *BBSConfig) PopulateFromProfile(p Profile) {
+	if c.URL == "" {
+		c.URL = p.BBSURL
+	}
+	if c.CertFile == "" {
+		c.CertFile = p.BBSCertFile
+	}
+	if c.KeyFile == "" {
+		c.KeyFile = p.BBSKeyFile
+	}
+	if c.CAFile == "" {
+		c.CAFile = p.BBSCAFile
+	}
+	if c.ServerName == "" {
+		c.ServerName = p.BBSServerName
+	}
 }
 
 func (c *BBSConfig) Validate() error {
 	if c.URL == "" {
-		return errors.New("You must set BBS_ENDPOINT")
+		return errors.New("You must set BBS_ENDPOINT or bbs_url in your profile")
 	}
 	if c.IsSecure() {
 		if c.CertFile == "" {
-			return errors.New("You must set BBS_CERT_FILE")
+			return errors.New("You must set BBS_CERT_FILE or bbs_cert_file in your profile")
 		}
 		if c.KeyFile == "" {
-			return errors.New("You must set BBS_KEY_FILE")
+			return errors.New("You must set BBS_KEY_FILE or bbs_key_file in your profile")
 		}
 	}
 
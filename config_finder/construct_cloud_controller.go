@@ -0,0 +1,53 @@
+package config_finder
+
+import (
+	"crypto/tls"
+	"os"
+
+	"github.com/luan/dope/cloud_controller"
+)
+
+// CloudControllerConfig enables looking up app, space and org names.
+type CloudControllerConfig struct {
+	URL string
+	// CAFile verifies the Cloud Controller certificate, the system roots
+	// are used if it is empty.
+	CAFile     string
+	SkipVerify bool
+}
+
+// NewCloudController returns nil when no Cloud Controller is configured,
+// which leaves LRPs known by their process guid only.
+func NewCloudController(c CloudControllerConfig, token func() (string, error)) (*cloud_controller.Client, error) {
+	if c.URL == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cloud_controller.NewClient(c.URL, token, tlsConfig), nil
+}
+
+func (c *CloudControllerConfig) TLSConfig() (*tls.Config, error) {
+	return newTLSConfig(c.CAFile, "", c.SkipVerify)
+}
+
+func (c *CloudControllerConfig) PopulateFromEnv() {
+	if c.URL == "" {
+		c.URL = os.Getenv("CLOUD_CONTROLLER_URL")
+	}
+	if c.CAFile == "" {
+		c.CAFile = os.Getenv("CLOUD_CONTROLLER_CA_FILE")
+	}
+}
+
+func (c *CloudControllerConfig) PopulateFromProfile(p Profile) {
+	if c.URL == "" {
+		c.URL = p.CloudControllerURL
+	}
+	if c.CAFile == "" {
+		c.CAFile = p.CloudControllerCAFile
+	}
+}
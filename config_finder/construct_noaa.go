@@ -10,29 +10,42 @@ import (
 )
 
 type NoaaConfig struct {
-	trafficControllerURL string
+	TrafficControllerURL string
+	// CAFile verifies the traffic controller certificate, the system roots
+	// are used if it is empty.
+	CAFile string
+	// ServerName is checked against the certificate instead of the host of
+	// the URL.
+	ServerName string
+	SkipVerify bool
 }
 
-func NewNoaaConsumer() (*noaa.Consumer, error) {
-	config := NoaaConfig{}
-	config.PopulateFromEnv()
-
+func NewNoaaConsumer(config NoaaConfig) (*noaa.Consumer, error) {
 	err := config.Validate()
 	if err != nil {
 		return nil, err
 	}
 
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	noaaClient := noaa.NewConsumer(
-		config.trafficControllerURL,
-		&tls.Config{InsecureSkipVerify: config.IsSecure()},
+		config.TrafficControllerURL,
+		tlsConfig,
 		nil,
 	)
 
 	return noaaClient, nil
 }
 
+func (c *NoaaConfig) TLSConfig() (*tls.Config, error) {
+	return newTLSConfig(c.CAFile, c.ServerName, c.SkipVerify)
+}
+
 func (c *NoaaConfig) IsSecure() bool {
-	u, err := url.Parse(c.trafficControllerURL)
+	u, err := url.Parse(c.TrafficControllerURL)
 	if err != nil {
 		panic("crap")
 	}
@@ -40,14 +53,32 @@ func (c *NoaaConfig) IsSecure() bool {
 }
 
 func (c *NoaaConfig) PopulateFromEnv() {
-	if c.trafficControllerURL == "" {
-		c.trafficControllerURL = os.Getenv("TRAFFIC_CONTROLLER_URL")
+	if c.TrafficControllerURL == "" {
+		c.TrafficControllerURL = os.Getenv("TRAFFIC_CONTROLLER_URL")
+	}
+	if c.CAFile == "" {
+		c.CAFile = os.Getenv("TRAFFIC_CONTROLLER_CA_FILE")
+	}
+	if c.ServerName == "" {
+		c.ServerName = os.Getenv("TRAFFIC_CONTROLLER_SERVER_NAME")
+	}
+}
+
+func (c *NoaaConfig) PopulateFromProfile(p Profile) {
+	if c.TrafficControllerURL == "" {
+		c.TrafficControllerURL = p.TrafficControllerURL
+	}
+	if c.CAFile == "" {
+		c.CAFile = p.TrafficControllerCAFile
+	}
+	if c.ServerName == "" {
+		c.ServerName = p.TrafficControllerServerName
 	}
 }
 
 func (c *NoaaConfig) Validate() error {
-	if c.trafficControllerURL == "" {
-		return errors.New("You must set TRAFFIC_CONTROLLER_URL")
+	if c.TrafficControllerURL == "" {
+		return errors.New("You must set TRAFFIC_CONTROLLER_URL or traffic_controller_url in your profile")
 	}
 
 	return nil
@@ -0,0 +1,101 @@
+package config_finder
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+
+	"github.com/luan/dope/uaa"
+)
+
+type UAAConfig struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	// CAFile verifies the UAA certificate, the system roots are used if it
+	// is empty.
+	CAFile     string
+	SkipVerify bool
+}
+
+func (c *UAAConfig) TLSConfig() (*tls.Config, error) {
+	return newTLSConfig(c.CAFile, "", c.SkipVerify)
+}
+
+// NewTokenSource picks where OAuth tokens come from: a fixed token if one
+// is configured, then a UAA client, then the cf CLI's login.
+func NewTokenSource(c Config) (func() (string, error), error) {
+	if c.OAuthToken != "" {
+		token := c.OAuthToken
+		return func() (string, error) {
+			return token, nil
+		}, nil
+	}
+
+	if c.UAA.URL != "" && c.UAA.ClientID != "" {
+		tlsConfig, err := c.UAA.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		if c.UAA.RefreshToken != "" {
+			return uaa.NewRefreshTokenProvider(c.UAA.URL, c.UAA.ClientID, c.UAA.ClientSecret, c.UAA.RefreshToken, tlsConfig).Token, nil
+		}
+		return uaa.NewClientCredentialsProvider(c.UAA.URL, c.UAA.ClientID, c.UAA.ClientSecret, tlsConfig).Token, nil
+	}
+
+	cfConfig, err := uaa.LoadCFConfig(uaa.CFConfigPath())
+	if err == nil && cfConfig.RefreshToken != "" && cfConfig.UaaEndpoint != "" {
+		tlsConfig, err := newTLSConfig(c.UAA.CAFile, "", c.UAA.SkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		return uaa.NewRefreshTokenProvider(cfConfig.UaaEndpoint, "cf", "", cfConfig.RefreshToken, tlsConfig).Token, nil
+	}
+	if err == nil && cfConfig.AccessToken != "" {
+		token := cfConfig.AccessToken
+		return func() (string, error) {
+			return token, nil
+		}, nil
+	}
+
+	return func() (string, error) {
+		return "", errors.New("no OAuth token: set OAUTH_TOKEN, a UAA client in your profile, or log in with the cf CLI")
+	}, nil
+}
+
+func (c *UAAConfig) PopulateFromEnv() {
+	if c.URL == "" {
+		c.URL = os.Getenv("UAA_URL")
+	}
+	if c.ClientID == "" {
+		c.ClientID = os.Getenv("UAA_CLIENT_ID")
+	}
+	if c.ClientSecret == "" {
+		c.ClientSecret = os.Getenv("UAA_CLIENT_SECRET")
+	}
+	if c.RefreshToken == "" {
+		c.RefreshToken = os.Getenv("UAA_REFRESH_TOKEN")
+	}
+	if c.CAFile == "" {
+		c.CAFile = os.Getenv("UAA_CA_FILE")
+	}
+}
+
+func (c *UAAConfig) PopulateFromProfile(p Profile) {
+	if c.URL == "" {
+		c.URL = p.UAAURL
+	}
+	if c.ClientID == "" {
+		c.ClientID = p.UAAClientID
+	}
+	if c.ClientSecret == "" {
+		c.ClientSecret = p.UAAClientSecret
+	}
+	if c.RefreshToken == "" {
+		c.RefreshToken = p.UAARefreshToken
+	}
+	if c.CAFile == "" {
+		c.CAFile = p.UAACAFile
+	}
+}
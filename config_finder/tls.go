@@ -0,0 +1,32 @@
+package config_finder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// newTLSConfig verifies certificates against caFile, or the system roots if
+// it is empty, and checks them for serverName if it is set instead of the
+// host that is dialed.
+func newTLSConfig(caFile, serverName string, skipVerify bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: skipVerify,
+	}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	caCert, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.RootCAs = x509.NewCertPool()
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return tlsConfig, nil
+}
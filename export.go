@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/luan/dope/export"
+	"github.com/luan/dope/fetcher"
+)
+
+// runExport writes a single snapshot. JSON goes to output, or stdout when
+// output is empty; CSV writes one file per table into the output directory.
+func runExport(f fetcher.Fetcher, format, output string) error {
+	state, err := f.Fetch()
+	if fetchErr, ok := err.(*fetcher.FetchError); ok && fetchErr.LRPs == nil {
+		// still export the LRPs, only tasks, cells or metrics are missing
+		fmt.Fprintln(os.Stderr, err)
+	} else if err != nil {
+		return err
+	}
+	snapshot := export.NewSnapshot(&state, state.Timestamp)
+
+	switch format {
+	case "json":
+		if output == "" {
+			return export.WriteJSON(os.Stdout, snapshot)
+		}
+		return writeFile(output, func(w io.Writer) error {
+			return export.WriteJSON(w, snapshot)
+		})
+	case "csv":
+		if output == "" {
+			output = "."
+		}
+		tables := map[string]func(io.Writer, export.Snapshot) error{
+			"instances.csv": export.WriteInstancesCSV,
+			"cells.csv":     export.WriteCellsCSV,
+			"tasks.csv":     export.WriteTasksCSV,
+		}
+		for name, writeTable := range tables {
+			writeTable := writeTable
+			err := writeFile(filepath.Join(output, name), func(w io.Writer) error {
+				return writeTable(w, snapshot)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q, expected json or csv", format)
+	}
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/luan/dope/fetcher"
+)
+
+func TestEmptySnapshotJSON(t *testing.T) {
+	now := time.Date(2016, 5, 4, 3, 2, 1, 0, time.UTC)
+	fields := jsonObject(t, NewSnapshot(&fetcher.Data{}, now))
+
+	expectKeys(t, fields, "cells", "domains", "lrps", "orphans", "tasks", "timestamp", "version")
+	if string(fields["version"]) != "1" {
+		t.Errorf("unexpected version %s", fields["version"])
+	}
+	if string(fields["timestamp"]) != `"2016-05-04T03:02:01Z"` {
+		t.Errorf("unexpected timestamp %s", fields["timestamp"])
+	}
+	for _, list := range []string{"cells", "domains", "lrps", "orphans", "tasks"} {
+		if string(fields[list]) != "[]" {
+			t.Errorf("expected %s to be an empty list, got %s", list, fields[list])
+		}
+	}
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	snapshot := NewSnapshot(testData(), time.Unix(0, 0))
+
+	var lrps []map[string]json.RawMessage
+	unmarshal(t, jsonObject(t, snapshot)["lrps"], &lrps)
+	if len(lrps) != 1 {
+		t.Fatalf("expected 1 LRP, got %d", len(lrps))
+	}
+	expectKeys(t, lrps[0],
+		"actuals", "annotation", "disk_mb", "domain", "instances", "log_guid",
+		"memory_mb", "process_guid", "routes",
+	)
+
+	var actuals []map[string]json.RawMessage
+	unmarshal(t, lrps[0]["actuals"], &actuals)
+	if len(actuals) != 1 {
+		t.Fatalf("expected 1 instance, got %d", len(actuals))
+	}
+	expectKeys(t, actuals[0],
+		"address", "cell_id", "crash_count", "crash_reason", "evacuating", "index",
+		"instance_guid", "metrics", "ports", "process_guid", "since", "state",
+	)
+
+	var metrics map[string]json.RawMessage
+	unmarshal(t, actuals[0]["metrics"], &metrics)
+	expectKeys(t, metrics, "cpu", "disk_bytes", "memory_bytes")
+
+	if len(snapshot.Orphans) != 1 || snapshot.Orphans[0].ProcessGuid != "orphan-guid" {
+		t.Errorf("unexpected orphans %v", snapshot.Orphans)
+	}
+}
+
+func TestWriteInstancesCSV(t *testing.T) {
+	rows := csvRows(t, WriteInstancesCSV, NewSnapshot(testData(), time.Unix(0, 0)))
+
+	expectRow(t, rows[0],
+		"process_guid", "domain", "index", "instance_guid", "cell_id", "state",
+		"evacuating", "crash_count", "since", "cpu",
+		"memory_bytes", "memory_limit_bytes", "disk_bytes", "disk_limit_bytes",
+		"orphan",
+	)
+	if len(rows) != 3 {
+		t.Fatalf("expected an instance and an orphan, got %d rows", len(rows)-1)
+	}
+	expectRow(t, rows[1],
+		"web-guid", "cf-apps", "0", "instance-guid", "cell_z1-0", "RUNNING",
+		"false", "0", "1970-01-01T00:00:00Z", "0.5",
+		"1024", "4294967296", "2048", "1073741824",
+		"false",
+	)
+	expectRow(t, rows[2],
+		"orphan-guid", "", "1", "", "cell_z2-0", "CLAIMED",
+		"false", "0", "1970-01-01T00:00:00Z", "0",
+		"0", "", "0", "",
+		"true",
+	)
+}
+
+func TestWriteCellsCSV(t *testing.T) {
+	rows := csvRows(t, WriteCellsCSV, NewSnapshot(testData(), time.Unix(0, 0)))
+
+	expectRow(t, rows[0],
+		"cell_id", "num_lrps", "num_tasks", "cpu",
+		"memory_used_bytes", "memory_reserved_bytes", "disk_used_bytes", "disk_reserved_bytes",
+		"zone", "memory_capacity_bytes", "disk_capacity_bytes", "container_capacity",
+	)
+	for _, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			t.Errorf("row %v does not match the header", row)
+		}
+	}
+}
+
+func TestWriteTasksCSV(t *testing.T) {
+	rows := csvRows(t, WriteTasksCSV, NewSnapshot(testData(), time.Unix(0, 0)))
+
+	expectRow(t, rows[0],
+		"task_guid", "domain", "state", "cell_id", "memory_mb", "disk_mb",
+		"failed", "failure_reason", "result", "created_at", "updated_at",
+	)
+	if len(rows) != 2 {
+		t.Fatalf("expected 1 task, got %d rows", len(rows)-1)
+	}
+	expectRow(t, rows[1],
+		"task-guid", "cf-tasks", "Completed", "cell_z1-0", "256", "512",
+		"true", "exit status 1, with a comma", "", "1970-01-01T00:00:00Z", "1970-01-01T00:00:00Z",
+	)
+}
+
+func testData() *fetcher.Data {
+	actual := &models.ActualLRP{State: "RUNNING"}
+	actual.ProcessGuid = "web-guid"
+	actual.InstanceGuid = "instance-guid"
+	actual.CellId = "cell_z1-0"
+
+	orphan := &models.ActualLRP{State: "CLAIMED"}
+	orphan.ProcessGuid = "orphan-guid"
+	orphan.Index = 1
+	orphan.CellId = "cell_z2-0"
+
+	task := &models.Task{
+		TaskDefinition: &models.TaskDefinition{MemoryMb: 256, DiskMb: 512},
+		TaskGuid:       "task-guid",
+		Domain:         "cf-tasks",
+		State:          models.Task_Completed,
+		CellId:         "cell_z1-0",
+		Failed:         true,
+		FailureReason:  "exit status 1, with a comma",
+	}
+
+	return &fetcher.Data{
+		Domains: []string{"cf-apps"},
+		LRPs: fetcher.LRPs{
+			"web-guid": &fetcher.LRP{
+				// large enough to overflow an int32 in bytes
+				Desired: &models.DesiredLRP{ProcessGuid: "web-guid", Domain: "cf-apps", Instances: 1, MemoryMb: 4096, DiskMb: 1024},
+				Actuals: []*fetcher.Actual{{
+					ActualLRP: actual,
+					Metrics:   fetcher.ContainerMetrics{CPU: 0.5, Memory: 1024, Disk: 2048},
+				}},
+			},
+		},
+		Orphans: fetcher.Orphans{{ActualLRP: orphan}},
+		Tasks:   fetcher.Tasks{task},
+	}
+}
+
+func jsonObject(t *testing.T, snapshot Snapshot) map[string]json.RawMessage {
+	buffer := &bytes.Buffer{}
+	err := WriteJSON(buffer, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := map[string]json.RawMessage{}
+	unmarshal(t, buffer.Bytes(), &fields)
+	return fields
+}
+
+func unmarshal(t *testing.T, data []byte, v interface{}) {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectKeys(t *testing.T, fields map[string]json.RawMessage, keys ...string) {
+	actual := []string{}
+	for key := range fields {
+		actual = append(actual, key)
+	}
+	sort.Strings(actual)
+
+	if strings.Join(actual, " ") != strings.Join(keys, " ") {
+		t.Errorf("expected fields %v, got %v", keys, actual)
+	}
+}
+
+func csvRows(t *testing.T, write func(w io.Writer, snapshot Snapshot) error, snapshot Snapshot) [][]string {
+	buffer := &bytes.Buffer{}
+	err := write(buffer, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func expectRow(t *testing.T, row []string, expected ...string) {
+	if strings.Join(row, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected row\n%v\ngot\n%v", expected, row)
+	}
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/luan/dope/fetcher"
+)
+
+// SchemaVersion is bumped whenever a field is renamed or removed from the
+// snapshot. Adding fields does not change the version.
+const SchemaVersion = 1
+
+type Snapshot struct {
+	Version   int        `json:"version"`
+	Timestamp time.Time  `json:"timestamp"`
+	Domains   []string   `json:"domains"`
+	LRPs      []LRP      `json:"lrps"`
+	Orphans   []Instance `json:"orphans"`
+	Tasks     []Task     `json:"tasks"`
+	Cells     []Cell     `json:"cells"`
+}
+
+type LRP struct {
+	ProcessGuid string                      `json:"process_guid"`
+	Domain      string                      `json:"domain"`
+	LogGuid     string                      `json:"log_guid"`
+	Instances   int32                       `json:"instances"`
+	MemoryMb    int32                       `json:"memory_mb"`
+	DiskMb      int32                       `json:"disk_mb"`
+	Annotation  string                      `json:"annotation"`
+	Routes      map[string]*json.RawMessage `json:"routes"`
+	Actuals     []Instance                  `json:"actuals"`
+}
+
+type Instance struct {
+	ProcessGuid  string    `json:"process_guid"`
+	Index        int32     `json:"index"`
+	InstanceGuid string    `json:"instance_guid"`
+	CellId       string    `json:"cell_id"`
+	State        string    `json:"state"`
+	Evacuating   bool      `json:"evacuating"`
+	Address      string    `json:"address"`
+	Ports        []Port    `json:"ports"`
+	CrashCount   int32     `json:"crash_count"`
+	CrashReason  string    `json:"crash_reason"`
+	Since        time.Time `json:"since"`
+	Metrics      Metrics   `json:"metrics"`
+}
+
+type Port struct {
+	ContainerPort uint32 `json:"container_port"`
+	HostPort      uint32 `json:"host_port"`
+}
+
+type Metrics struct {
+	CPU         float64 `json:"cpu"`
+	MemoryBytes uint64  `json:"memory_bytes"`
+	DiskBytes   uint64  `json:"disk_bytes"`
+}
+
+type Task struct {
+	TaskGuid      string    `json:"task_guid"`
+	Domain        string    `json:"domain"`
+	State         string    `json:"state"`
+	CellId        string    `json:"cell_id"`
+	MemoryMb      int32     `json:"memory_mb"`
+	DiskMb        int32     `json:"disk_mb"`
+	Failed        bool      `json:"failed"`
+	FailureReason string    `json:"failure_reason"`
+	Result        string    `json:"result"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type Cell struct {
+	CellId            string   `json:"cell_id"`
+	NumLRPs           uint64   `json:"num_lrps"`
+	NumTasks          uint64   `json:"num_tasks"`
+	CPU               float64  `json:"cpu"`
+	MemoryUsed        uint64   `json:"memory_used_bytes"`
+	MemoryReserved    uint64   `json:"memory_reserved_bytes"`
+	DiskUsed          uint64   `json:"disk_used_bytes"`
+	DiskReserved      uint64   `json:"disk_reserved_bytes"`
+	Zone              string   `json:"zone"`
+	MemoryCapacity    uint64   `json:"memory_capacity_bytes"`
+	DiskCapacity      uint64   `json:"disk_capacity_bytes"`
+	ContainerCapacity uint64   `json:"container_capacity"`
+	RootfsProviders   []string `json:"rootfs_providers"`
+}
+
+func NewSnapshot(data *fetcher.Data, now time.Time) Snapshot {
+	snapshot := Snapshot{
+		Version:   SchemaVersion,
+		Timestamp: now,
+		Domains:   data.Domains,
+		LRPs:      []LRP{},
+		Orphans:   []Instance{},
+		Tasks:     []Task{},
+		Cells:     []Cell{},
+	}
+	if snapshot.Domains == nil {
+		snapshot.Domains = []string{}
+	}
+
+	for _, lrp := range data.LRPs.SortedByProcessGuid() {
+		snapshot.LRPs = append(snapshot.LRPs, newLRP(lrp))
+	}
+
+	for _, actual := range data.Orphans.SortedByProcessGuid() {
+		snapshot.Orphans = append(snapshot.Orphans, newInstance(actual))
+	}
+
+	for _, task := range data.Tasks {
+		snapshot.Tasks = append(snapshot.Tasks, newTask(task))
+	}
+
+	for _, cell := range data.GetCellState().SortedByCellId() {
+		rootfsProviders := cell.RootfsProviders
+		if rootfsProviders == nil {
+			rootfsProviders = []string{}
+		}
+		snapshot.Cells = append(snapshot.Cells, Cell{
+			CellId:            cell.CellId,
+			NumLRPs:           cell.NumLRPs,
+			NumTasks:          cell.NumTasks,
+			CPU:               cell.CPUPercentage,
+			MemoryUsed:        cell.MemoryUsed,
+			MemoryReserved:    cell.MemoryReserved,
+			DiskUsed:          cell.DiskUsed,
+			DiskReserved:      cell.DiskReserved,
+			Zone:              cell.Zone,
+			MemoryCapacity:    cell.MemoryCapacity,
+			DiskCapacity:      cell.DiskCapacity,
+			ContainerCapacity: cell.ContainerCapacity,
+			RootfsProviders:   rootfsProviders,
+		})
+	}
+
+	return snapshot
+}
+
+func newLRP(lrp *fetcher.LRP) LRP {
+	exported := LRP{
+		ProcessGuid: lrp.Desired.ProcessGuid,
+		Domain:      lrp.Desired.Domain,
+		LogGuid:     lrp.Desired.LogGuid,
+		Instances:   lrp.Desired.Instances,
+		MemoryMb:    lrp.Desired.MemoryMb,
+		DiskMb:      lrp.Desired.DiskMb,
+		Annotation:  lrp.Desired.Annotation,
+		Routes:      map[string]*json.RawMessage{},
+		Actuals:     []Instance{},
+	}
+	if lrp.Desired.Routes != nil {
+		exported.Routes = *lrp.Desired.Routes
+	}
+
+	for _, actual := range lrp.ActualLRPsByIndex(false) {
+		exported.Actuals = append(exported.Actuals, newInstance(actual))
+	}
+
+	return exported
+}
+
+func newInstance(actual *fetcher.Actual) Instance {
+	instance := Instance{
+		ProcessGuid:  actual.ActualLRP.ProcessGuid,
+		Index:        actual.ActualLRP.Index,
+		InstanceGuid: actual.ActualLRP.InstanceGuid,
+		CellId:       actual.ActualLRP.CellId,
+		State:        actual.ActualLRP.State,
+		Evacuating:   actual.Evacuating,
+		Address:      actual.ActualLRP.Address,
+		Ports:        []Port{},
+		CrashCount:   actual.ActualLRP.CrashCount,
+		CrashReason:  actual.ActualLRP.CrashReason,
+		Since:        time.Unix(0, actual.ActualLRP.Since).UTC(),
+		Metrics: Metrics{
+			CPU:         actual.Metrics.CPU,
+			MemoryBytes: actual.Metrics.Memory,
+			DiskBytes:   actual.Metrics.Disk,
+		},
+	}
+
+	for _, port := range actual.ActualLRP.Ports {
+		instance.Ports = append(instance.Ports, Port{
+			ContainerPort: port.ContainerPort,
+			HostPort:      port.HostPort,
+		})
+	}
+
+	return instance
+}
+
+func newTask(task *models.Task) Task {
+	return Task{
+		TaskGuid:      task.TaskGuid,
+		Domain:        task.Domain,
+		State:         task.State.String(),
+		CellId:        task.CellId,
+		MemoryMb:      task.MemoryMb,
+		DiskMb:        task.DiskMb,
+		Failed:        task.Failed,
+		FailureReason: task.FailureReason,
+		Result:        task.Result,
+		CreatedAt:     time.Unix(0, task.CreatedAt).UTC(),
+		UpdatedAt:     time.Unix(0, task.UpdatedAt).UTC(),
+	}
+}
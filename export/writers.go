@@ -0,0 +1,144 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/luan/dope/fetcher"
+)
+
+func WriteJSON(w io.Writer, snapshot Snapshot) error {
+	bytes, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(bytes, '\n'))
+	return err
+}
+
+// WriteInstancesCSV writes a row for every instance, orphans included
+// with empty domain and limits since they have no desired LRP.
+func WriteInstancesCSV(w io.Writer, snapshot Snapshot) error {
+	rows := [][]string{{
+		"process_guid", "domain", "index", "instance_guid", "cell_id", "state",
+		"evacuating", "crash_count", "since", "cpu",
+		"memory_bytes", "memory_limit_bytes", "disk_bytes", "disk_limit_bytes",
+		"orphan",
+	}}
+
+	for _, lrp := range snapshot.LRPs {
+		for _, instance := range lrp.Actuals {
+			rows = append(rows, instanceRow(
+				instance, lrp.Domain,
+				fmtUint(fetcher.Megabytes(lrp.MemoryMb)), fmtUint(fetcher.Megabytes(lrp.DiskMb)),
+				false,
+			))
+		}
+	}
+	for _, instance := range snapshot.Orphans {
+		rows = append(rows, instanceRow(instance, "", "", "", true))
+	}
+
+	return writeCSV(w, rows)
+}
+
+func instanceRow(instance Instance, domain, memoryLimit, diskLimit string, orphan bool) []string {
+	return []string{
+		instance.ProcessGuid,
+		domain,
+		fmtInt(int64(instance.Index)),
+		instance.InstanceGuid,
+		instance.CellId,
+		instance.State,
+		strconv.FormatBool(instance.Evacuating),
+		fmtInt(int64(instance.CrashCount)),
+		fmtTime(instance.Since),
+		fmtFloat(instance.Metrics.CPU),
+		fmtUint(instance.Metrics.MemoryBytes),
+		memoryLimit,
+		fmtUint(instance.Metrics.DiskBytes),
+		diskLimit,
+		strconv.FormatBool(orphan),
+	}
+}
+
+func WriteCellsCSV(w io.Writer, snapshot Snapshot) error {
+	rows := [][]string{{
+		"cell_id", "num_lrps", "num_tasks", "cpu",
+		"memory_used_bytes", "memory_reserved_bytes", "disk_used_bytes", "disk_reserved_bytes",
+		"zone", "memory_capacity_bytes", "disk_capacity_bytes", "container_capacity",
+	}}
+
+	for _, cell := range snapshot.Cells {
+		rows = append(rows, []string{
+			cell.CellId,
+			fmtUint(cell.NumLRPs),
+			fmtUint(cell.NumTasks),
+			fmtFloat(cell.CPU),
+			fmtUint(cell.MemoryUsed),
+			fmtUint(cell.MemoryReserved),
+			fmtUint(cell.DiskUsed),
+			fmtUint(cell.DiskReserved),
+			cell.Zone,
+			fmtUint(cell.MemoryCapacity),
+			fmtUint(cell.DiskCapacity),
+			fmtUint(cell.ContainerCapacity),
+		})
+	}
+
+	return writeCSV(w, rows)
+}
+
+func WriteTasksCSV(w io.Writer, snapshot Snapshot) error {
+	rows := [][]string{{
+		"task_guid", "domain", "state", "cell_id", "memory_mb", "disk_mb",
+		"failed", "failure_reason", "result", "created_at", "updated_at",
+	}}
+
+	for _, task := range snapshot.Tasks {
+		rows = append(rows, []string{
+			task.TaskGuid,
+			task.Domain,
+			task.State,
+			task.CellId,
+			fmtInt(int64(task.MemoryMb)),
+			fmtInt(int64(task.DiskMb)),
+			strconv.FormatBool(task.Failed),
+			task.FailureReason,
+			task.Result,
+			fmtTime(task.CreatedAt),
+			fmtTime(task.UpdatedAt),
+		})
+	}
+
+	return writeCSV(w, rows)
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func fmtInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func fmtUint(i uint64) string {
+	return strconv.FormatUint(i, 10)
+}
+
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func fmtTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
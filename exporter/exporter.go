@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/luan/dope/fetcher"
+)
+
+// Exporter runs the fetch loop in the background and serves the latest
+// snapshot in the Prometheus text exposition format.
+type Exporter struct {
+	lock        sync.Mutex
+	data        *fetcher.Data
+	fetches     uint64
+	errors      map[string]uint64
+	durationSum float64
+	lastSuccess time.Time
+}
+
+func New() *Exporter {
+	return &Exporter{errors: map[string]uint64{}}
+}
+
+// fetchParts label the fetch errors by what failed, all being a fetch that
+// got nothing at all.
+var fetchParts = []string{"domains", "tasks", "cells", "lrps", "metrics", "all"}
+
+// Run fetches forever. Whatever fails to fetch is kept from the previous
+// snapshot. A fetch only counts as successful if it got the LRPs.
+func (e *Exporter) Run(f fetcher.Fetcher, interval time.Duration) {
+	for {
+		start := time.Now()
+		data, err := f.Fetch()
+		duration := time.Since(start)
+
+		e.lock.Lock()
+		e.fetches++
+		e.durationSum += duration.Seconds()
+		if fetchErr, ok := err.(*fetcher.FetchError); ok {
+			e.countErrors(fetchErr)
+			fetchErr.KeepPrevious(&data, e.data)
+			e.data = &data
+			if fetchErr.LRPs == nil {
+				e.lastSuccess = time.Now()
+			}
+		} else if err != nil {
+			e.errors["all"]++
+		} else {
+			e.data = &data
+			e.lastSuccess = time.Now()
+		}
+		e.lock.Unlock()
+
+		time.Sleep(interval)
+	}
+}
+
+func (e *Exporter) countErrors(err *fetcher.FetchError) {
+	if err.Domains != nil {
+		e.errors["domains"]++
+	}
+	if err.Tasks != nil {
+		e.errors["tasks"]++
+	}
+	if err.Cells != nil {
+		e.errors["cells"]++
+	}
+	if err.LRPs != nil {
+		e.errors["lrps"]++
+	}
+	if err.Metrics != nil {
+		e.errors["metrics"]++
+	}
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	mw := &metricsWriter{w: w}
+
+	mw.family("dope_fetches_total", "counter", "Number of fetches from the BBS.")
+	mw.sample("dope_fetches_total", nil, float64(e.fetches))
+	mw.family("dope_fetch_errors_total", "counter", "Number of fetches from the BBS that failed, by the part that failed.")
+	for _, part := range fetchParts {
+		mw.sample("dope_fetch_errors_total", []string{"part", part}, float64(e.errors[part]))
+	}
+	mw.family("dope_fetch_duration_seconds", "summary", "Time spent fetching from the BBS.")
+	mw.sample("dope_fetch_duration_seconds_sum", nil, e.durationSum)
+	mw.sample("dope_fetch_duration_seconds_count", nil, float64(e.fetches))
+	if !e.lastSuccess.IsZero() {
+		mw.family("dope_last_successful_fetch_timestamp_seconds", "gauge", "Unix time of the last fetch that got the LRPs.")
+		mw.sample("dope_last_successful_fetch_timestamp_seconds", nil, float64(e.lastSuccess.Unix()))
+	}
+
+	if e.data != nil {
+		writeData(mw, e.data)
+	}
+}
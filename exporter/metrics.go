@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/luan/dope/fetcher"
+)
+
+func writeData(mw *metricsWriter, data *fetcher.Data) {
+	lrps := data.LRPs.SortedByProcessGuid()
+
+	type instanceMetric struct {
+		name, help string
+		value      func(*fetcher.Actual) float64
+	}
+	instanceMetrics := []instanceMetric{
+		{"dope_instance_cpu_ratio", "CPU usage of an instance, 1 being one core.",
+			func(a *fetcher.Actual) float64 { return a.Metrics.CPU }},
+		{"dope_instance_memory_bytes", "Memory used by an instance.",
+			func(a *fetcher.Actual) float64 { return float64(a.Metrics.Memory) }},
+		{"dope_instance_disk_bytes", "Disk used by an instance.",
+			func(a *fetcher.Actual) float64 { return float64(a.Metrics.Disk) }},
+	}
+	for _, metric := range instanceMetrics {
+		mw.family(metric.name, "gauge", metric.help)
+		for _, lrp := range lrps {
+			for _, actual := range lrp.ActualLRPsByIndex(false) {
+				mw.sample(metric.name, []string{
+					"process_guid", lrp.Desired.ProcessGuid,
+					"domain", lrp.Desired.Domain,
+					"index", strconv.Itoa(int(actual.ActualLRP.Index)),
+					"instance_guid", actual.ActualLRP.InstanceGuid,
+					"cell_id", actual.ActualLRP.CellId,
+					"state", actual.ActualLRP.State,
+				}, metric.value(actual))
+			}
+		}
+	}
+
+	mw.family("dope_lrp_desired_instances", "gauge", "Number of instances desired for an LRP.")
+	for _, lrp := range lrps {
+		mw.sample("dope_lrp_desired_instances", lrpLabels(lrp), float64(lrp.Desired.Instances))
+	}
+
+	mw.family("dope_lrp_actual_instances", "gauge", "Number of actual instances of an LRP, in any state.")
+	for _, lrp := range lrps {
+		mw.sample("dope_lrp_actual_instances", lrpLabels(lrp), float64(len(lrp.Actuals)))
+	}
+
+	placement := data.Placement()
+	mw.family("dope_lrp_zone_instances", "gauge", "Number of placed instances of an LRP in a zone.")
+	for _, lrp := range lrps {
+		distribution := placement.Distribution(lrp)
+		for _, zone := range sortedKeys(distribution) {
+			mw.sample("dope_lrp_zone_instances", append(lrpLabels(lrp), "zone", zone), float64(distribution[zone]))
+		}
+	}
+
+	mw.family("dope_lrp_single_zone", "gauge", "1 if every instance of an LRP is placed in the same zone while cells span several.")
+	for _, lrp := range lrps {
+		singleZone := 0.0
+		if placement.SingleZone(lrp) {
+			singleZone = 1
+		}
+		mw.sample("dope_lrp_single_zone", lrpLabels(lrp), singleZone)
+	}
+
+	actualStates := map[string]int{}
+	for _, lrp := range lrps {
+		for _, actual := range lrp.Actuals {
+			actualStates[actual.ActualLRP.State]++
+		}
+	}
+	mw.family("dope_actual_lrps", "gauge", "Number of actual LRPs by state.")
+	for _, state := range sortedKeys(actualStates) {
+		mw.sample("dope_actual_lrps", []string{"state", state}, float64(actualStates[state]))
+	}
+
+	orphanStates := map[string]int{}
+	for _, actual := range data.Orphans {
+		orphanStates[actual.ActualLRP.State]++
+	}
+	mw.family("dope_orphaned_actual_lrps", "gauge", "Number of actual LRPs without a desired LRP by state.")
+	for _, state := range sortedKeys(orphanStates) {
+		mw.sample("dope_orphaned_actual_lrps", []string{"state", state}, float64(orphanStates[state]))
+	}
+
+	taskStates := map[string]int{}
+	for _, task := range data.Tasks {
+		taskStates[task.State.String()]++
+	}
+	mw.family("dope_tasks", "gauge", "Number of tasks by state.")
+	for _, state := range sortedKeys(taskStates) {
+		mw.sample("dope_tasks", []string{"state", state}, float64(taskStates[state]))
+	}
+
+	type cellMetric struct {
+		name, help string
+		value      func(*fetcher.CellState) float64
+	}
+	cellMetrics := []cellMetric{
+		{"dope_cell_cpu_ratio", "CPU used by all instances on a cell, 1 being one core.",
+			func(c *fetcher.CellState) float64 { return c.CPUPercentage }},
+		{"dope_cell_memory_used_bytes", "Memory used by all instances on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.MemoryUsed) }},
+		{"dope_cell_memory_reserved_bytes", "Memory reserved by all instances on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.MemoryReserved) }},
+		{"dope_cell_disk_used_bytes", "Disk used by all instances on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.DiskUsed) }},
+		{"dope_cell_disk_reserved_bytes", "Disk reserved by all instances on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.DiskReserved) }},
+		{"dope_cell_lrps", "Number of actual LRPs on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.NumLRPs) }},
+		{"dope_cell_tasks", "Number of tasks on a cell.",
+			func(c *fetcher.CellState) float64 { return float64(c.NumTasks) }},
+		{"dope_cell_memory_capacity_bytes", "Memory a cell advertises to the BBS.",
+			func(c *fetcher.CellState) float64 { return float64(c.MemoryCapacity) }},
+		{"dope_cell_disk_capacity_bytes", "Disk a cell advertises to the BBS.",
+			func(c *fetcher.CellState) float64 { return float64(c.DiskCapacity) }},
+		{"dope_cell_containers_capacity", "Number of containers a cell advertises to the BBS.",
+			func(c *fetcher.CellState) float64 { return float64(c.ContainerCapacity) }},
+	}
+	cells := data.GetCellState().SortedByCellId()
+	for _, metric := range cellMetrics {
+		mw.family(metric.name, "gauge", metric.help)
+		for _, cell := range cells {
+			mw.sample(metric.name, []string{"cell_id", cell.CellId, "zone", cell.Zone}, metric.value(cell))
+		}
+	}
+}
+
+func lrpLabels(lrp *fetcher.LRP) []string {
+	return []string{
+		"process_guid", lrp.Desired.ProcessGuid,
+		"domain", lrp.Desired.Domain,
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type metricsWriter struct {
+	w io.Writer
+}
+
+func (mw *metricsWriter) family(name, metricType, help string) {
+	fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample writes a single line; labels alternate between names and values.
+func (mw *metricsWriter) sample(name string, labels []string, value float64) {
+	pairs := []string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], escapeLabel(labels[i+1])))
+	}
+
+	if len(pairs) > 0 {
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(mw.w, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
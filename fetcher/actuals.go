@@ -18,6 +18,163 @@ type ContainerMetrics struct {
 	Disk   uint64
 }
 
+// setActual stores both sides of the group, so that an evacuating instance
+// and its replacement are kept side by side. It returns false if there is
+// no desired LRP for the actual.
+func (l LRPs) setActual(actualLRPGroup *models.ActualLRPGroup) bool {
+	lrp, ok := l[groupProcessGuid(actualLRPGroup)]
+	if !ok {
+		return false
+	}
+
+	lrp.Actuals = setActualGroup(lrp.Actuals, actualLRPGroup)
+	return true
+}
+
+func (l LRPs) removeActual(actualLRPGroup *models.ActualLRPGroup) {
+	lrp, ok := l[groupProcessGuid(actualLRPGroup)]
+	if !ok {
+		return
+	}
+
+	lrp.Actuals = removeActualGroup(lrp.Actuals, actualLRPGroup)
+}
+
+// Counterpart returns the other half of an evacuation: the replacement of
+// an evacuating instance, or the evacuating instance being replaced.
+func (l *LRP) Counterpart(actual *Actual) *Actual {
+	for _, other := range l.Actuals {
+		if other.ActualLRP.Index == actual.ActualLRP.Index &&
+			other.Evacuating != actual.Evacuating {
+			return other
+		}
+	}
+	return nil
+}
+
+func groupProcessGuid(actualLRPGroup *models.ActualLRPGroup) string {
+	if actualLRPGroup.Instance != nil {
+		return actualLRPGroup.Instance.ProcessGuid
+	}
+	if actualLRPGroup.Evacuating != nil {
+		return actualLRPGroup.Evacuating.ProcessGuid
+	}
+	return ""
+}
+
+// vanished returns the sides of before that are gone from after, e.g. the
+// evacuating instance once its replacement is running.
+func vanished(before, after *models.ActualLRPGroup) *models.ActualLRPGroup {
+	gone := &models.ActualLRPGroup{}
+	if after.Instance == nil {
+		gone.Instance = before.Instance
+	}
+	if after.Evacuating == nil {
+		gone.Evacuating = before.Evacuating
+	}
+	return gone
+}
+
+func setActualGroup(actuals []*Actual, actualLRPGroup *models.ActualLRPGroup) []*Actual {
+	if actualLRPGroup.Instance != nil {
+		actuals = setActual(actuals, actualLRPGroup.Instance, false)
+	}
+	if actualLRPGroup.Evacuating != nil {
+		actuals = setActual(actuals, actualLRPGroup.Evacuating, true)
+	}
+	return actuals
+}
+
+func removeActualGroup(actuals []*Actual, actualLRPGroup *models.ActualLRPGroup) []*Actual {
+	if actualLRPGroup.Instance != nil {
+		actuals = removeActual(actuals, actualLRPGroup.Instance, false)
+	}
+	if actualLRPGroup.Evacuating != nil {
+		actuals = removeActual(actuals, actualLRPGroup.Evacuating, true)
+	}
+	return actuals
+}
+
+// setActual replaces the actual with the same process guid, index and side
+// of the group, or appends a new one.
+func setActual(actuals []*Actual, actualLRP *models.ActualLRP, evacuating bool) []*Actual {
+	for _, actual := range actuals {
+		if actual.ActualLRP.ProcessGuid == actualLRP.ProcessGuid &&
+			actual.ActualLRP.Index == actualLRP.Index &&
+			actual.Evacuating == evacuating {
+			actual.ActualLRP = actualLRP
+			return actuals
+		}
+	}
+
+	return append(actuals, &Actual{ActualLRP: actualLRP, Evacuating: evacuating})
+}
+
+func removeActual(actuals []*Actual, actualLRP *models.ActualLRP, evacuating bool) []*Actual {
+	for i, actual := range actuals {
+		if actual.ActualLRP.ProcessGuid == actualLRP.ProcessGuid &&
+			actual.ActualLRP.Index == actualLRP.Index &&
+			actual.ActualLRP.InstanceGuid == actualLRP.InstanceGuid &&
+			actual.Evacuating == evacuating {
+			return append(actuals[:i], actuals[i+1:]...)
+		}
+	}
+	return actuals
+}
+
+func copyActuals(actuals []*Actual) []*Actual {
+	copies := make([]*Actual, len(actuals))
+	for i, actual := range actuals {
+		actualCopy := *actual
+		copies[i] = &actualCopy
+	}
+	return copies
+}
+
+func (l LRPs) applyMetrics(metrics instanceMetrics) {
+	for processGuid, byIndex := range metrics {
+		lrp, ok := l[processGuid]
+		if !ok {
+			continue
+		}
+
+		for _, actual := range lrp.Actuals {
+			// both halves of an evacuation report the same index, the
+			// metrics belong to the one the group resolves to, which is
+			// the evacuating instance until its replacement is running
+			if !lrp.resolves(actual) {
+				continue
+			}
+			if containerMetrics, ok := byIndex[actual.ActualLRP.Index]; ok {
+				actual.Metrics = containerMetrics
+			}
+		}
+	}
+}
+
+// keepMetrics gives the instances that got no metrics the ones they had in
+// previous.
+func (l LRPs) keepMetrics(previous LRPs) {
+	for processGuid, lrp := range l {
+		previousLRP, ok := previous[processGuid]
+		if !ok {
+			continue
+		}
+
+		for _, actual := range lrp.Actuals {
+			if actual.Metrics != (ContainerMetrics{}) {
+				continue
+			}
+			for _, previousActual := range previousLRP.Actuals {
+				if NewInstanceKey(previousActual) == NewInstanceKey(actual) &&
+					previousActual.Evacuating == actual.Evacuating {
+					actual.Metrics = previousActual.Metrics
+				}
+			}
+		}
+	}
+}
+
 func (l *LRP) actualsSorted(sortOrder func([]*Actual) sort.Interface, reversed bool) []*Actual {
 	actuals := make([]*Actual, len(l.Actuals))
 	copy(actuals, l.Actuals)
@@ -55,6 +212,9 @@ type ActualsByIndex []*Actual
 func (l ActualsByIndex) Len() int      { return len(l) }
 func (l ActualsByIndex) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
 func (l ActualsByIndex) Less(i, j int) bool {
+	if l[i].ActualLRP.Index == l[j].ActualLRP.Index {
+		return l[i].Evacuating && !l[j].Evacuating
+	}
 	return l[i].ActualLRP.Index < l[j].ActualLRP.Index
 }
 
@@ -0,0 +1,36 @@
+package fetcher
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes exponentially growing retry delays with jitter, so that
+// many dope instances do not retry against the BBS in lockstep.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+
+	attempts uint
+}
+
+func NewBackoff(min, max time.Duration) *Backoff {
+	return &Backoff{Min: min, Max: max}
+}
+
+// Next returns a delay between half and all of min * 2^attempts, capped
+// at max.
+func (b *Backoff) Next() time.Duration {
+	delay := b.Max
+	if b.attempts < 32 && b.Min<<b.attempts < b.Max {
+		delay = b.Min << b.attempts
+	}
+	b.attempts++
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (b *Backoff) Reset() {
+	b.attempts = 0
+}
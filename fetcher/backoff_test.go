@@ -0,0 +1,40 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsUpToMax(t *testing.T) {
+	b := NewBackoff(time.Second, 10*time.Second)
+
+	for _, delay := range []time.Duration{1, 2, 4, 8, 10, 10} {
+		delay *= time.Second
+		next := b.Next()
+		if next < delay/2 || next > delay {
+			t.Errorf("expected a delay between %s and %s, got %s", delay/2, delay, next)
+		}
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	b := NewBackoff(time.Second, time.Minute)
+	for i := 0; i < 5; i++ {
+		b.Next()
+	}
+
+	b.Reset()
+	if next := b.Next(); next > time.Second {
+		t.Errorf("expected at most 1s after a reset, got %s", next)
+	}
+}
+
+func TestBackoffDoesNotOverflow(t *testing.T) {
+	b := NewBackoff(time.Second, time.Hour)
+	for i := 0; i < 100; i++ {
+		next := b.Next()
+		if next <= 0 || next > time.Hour {
+			t.Fatalf("attempt %d: delay %s out of range", i, next)
+		}
+	}
+}
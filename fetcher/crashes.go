@@ -0,0 +1,165 @@
+package fetcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Crash is a single crash of an instance, noticed when its crash count went
+// up between two snapshots.
+type Crash struct {
+	Timestamp time.Time
+	Index     int32
+	CellId    string
+	Reason    string
+}
+
+// CrashingLRP holds the crashes of an LRP within the tracker's window,
+// newest first.
+type CrashingLRP struct {
+	ProcessGuid string
+	Crashes     []Crash
+	// Rate is the number of crashes per minute over the window.
+	Rate float64
+	// Looping holds the indexes that crashed at least crashLoopThreshold
+	// times within the window.
+	Looping []int32
+}
+
+// crashLoopThreshold is how many crashes within the window make an
+// instance crash-looping.
+const crashLoopThreshold = 3
+
+type crashKey struct {
+	ProcessGuid string
+	Index       int32
+}
+
+// CrashTracker follows the crash counts of every instance across snapshots.
+// The BBS keeps the crash count of an index while its instance guid changes
+// on every restart, so crashes are tracked per process guid and index.
+type CrashTracker struct {
+	window time.Duration
+
+	lock       sync.Mutex
+	recordedAt time.Time
+	counts     map[crashKey]int32
+	crashes    map[string][]Crash
+}
+
+// NewCrashTracker remembers crashes for window.
+func NewCrashTracker(window time.Duration) *CrashTracker {
+	return &CrashTracker{
+		window:  window,
+		counts:  map[crashKey]int32{},
+		crashes: map[string][]Crash{},
+	}
+}
+
+func (c *CrashTracker) Record(data *Data) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if timestamp.Before(c.recordedAt) {
+		// a replay went backwards, start over
+		c.counts = map[crashKey]int32{}
+		c.crashes = map[string][]Crash{}
+	}
+	c.recordedAt = timestamp
+
+	counts := map[crashKey]int32{}
+	for processGuid, lrp := range data.LRPs {
+		for _, actual := range lrp.Actuals {
+			if actual.Evacuating {
+				continue
+			}
+
+			key := crashKey{ProcessGuid: processGuid, Index: actual.ActualLRP.Index}
+			count := actual.ActualLRP.CrashCount
+			counts[key] = count
+
+			previous, seen := c.counts[key]
+			if !seen {
+				continue
+			}
+			for i := previous; i < count; i++ {
+				c.crashes[processGuid] = append(c.crashes[processGuid], Crash{
+					Timestamp: timestamp,
+					Index:     actual.ActualLRP.Index,
+					CellId:    actual.ActualLRP.CellId,
+					Reason:    actual.ActualLRP.CrashReason,
+				})
+			}
+		}
+	}
+	c.counts = counts
+
+	for processGuid, crashes := range c.crashes {
+		recent := []Crash{}
+		for _, crash := range crashes {
+			if timestamp.Sub(crash.Timestamp) < c.window {
+				recent = append(recent, crash)
+			}
+		}
+
+		if _, ok := data.LRPs[processGuid]; !ok || len(recent) == 0 {
+			delete(c.crashes, processGuid)
+		} else {
+			c.crashes[processGuid] = recent
+		}
+	}
+}
+
+// Crashing returns the LRPs that crashed within the window, the most
+// frequently crashing first.
+func (c *CrashTracker) Crashing() []*CrashingLRP {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	crashing := []*CrashingLRP{}
+	for processGuid, crashes := range c.crashes {
+		lrp := &CrashingLRP{
+			ProcessGuid: processGuid,
+			Rate:        float64(len(crashes)) / c.window.Minutes(),
+		}
+
+		perIndex := map[int32]int{}
+		for i := len(crashes) - 1; i >= 0; i-- {
+			lrp.Crashes = append(lrp.Crashes, crashes[i])
+			perIndex[crashes[i].Index]++
+		}
+		for index, count := range perIndex {
+			if count >= crashLoopThreshold {
+				lrp.Looping = append(lrp.Looping, index)
+			}
+		}
+		sort.Sort(int32s(lrp.Looping))
+
+		crashing = append(crashing, lrp)
+	}
+
+	sort.Sort(CrashingLRPsByRate(crashing))
+	return crashing
+}
+
+type CrashingLRPsByRate []*CrashingLRP
+
+func (l CrashingLRPsByRate) Len() int      { return len(l) }
+func (l CrashingLRPsByRate) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l CrashingLRPsByRate) Less(i, j int) bool {
+	if l[i].Rate == l[j].Rate {
+		return l[i].ProcessGuid < l[j].ProcessGuid
+	}
+	return l[i].Rate > l[j].Rate
+}
+
+type int32s []int32
+
+func (l int32s) Len() int           { return len(l) }
+func (l int32s) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l int32s) Less(i, j int) bool { return l[i] < l[j] }
@@ -33,6 +33,23 @@ func (l LRPsByProcessGuid) Less(i, j int) bool {
 	return l[i].Desired.ProcessGuid < l[j].Desired.ProcessGuid
 }
 
+func (l LRPs) desire(desiredLRP *models.DesiredLRP) {
+	lrp, ok := l[desiredLRP.ProcessGuid]
+	if !ok {
+		l[desiredLRP.ProcessGuid] = &LRP{Desired: desiredLRP}
+		return
+	}
+	lrp.Desired = desiredLRP
+}
+
+func (l LRPs) copy() LRPs {
+	lrps := LRPs{}
+	for processGuid, lrp := range l {
+		lrps[processGuid] = &LRP{Desired: lrp.Desired, Actuals: copyActuals(lrp.Actuals)}
+	}
+	return lrps
+}
+
 type LRP struct {
 	Desired *models.DesiredLRP
 	Actuals []*Actual
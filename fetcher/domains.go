@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// WellKnownDomains are the domains CF keeps fresh. The BBS does not
+// converge the LRPs of a domain that is not fresh, so these are expected to
+// always be there.
+var WellKnownDomains = []string{"cf-apps", "cf-tasks"}
+
+type DomainState struct {
+	Domain    string
+	Fresh     bool
+	WellKnown bool
+	// Since is when the domain was last seen turning fresh or stale, zero
+	// for a well-known domain that was never seen.
+	Since time.Time
+}
+
+// DomainTracker remembers every domain it saw and when it appeared or
+// disappeared between snapshots.
+type DomainTracker struct {
+	lock   sync.Mutex
+	states map[string]*DomainState
+}
+
+func NewDomainTracker() *DomainTracker {
+	states := map[string]*DomainState{}
+	for _, domain := range WellKnownDomains {
+		states[domain] = &DomainState{Domain: domain, WellKnown: true}
+	}
+	return &DomainTracker{states: states}
+}
+
+func (t *DomainTracker) Record(data *Data) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	fresh := map[string]bool{}
+	for _, domain := range data.Domains {
+		fresh[domain] = true
+
+		state, ok := t.states[domain]
+		if !ok {
+			state = &DomainState{Domain: domain}
+			t.states[domain] = state
+		}
+		if !state.Fresh {
+			state.Fresh = true
+			state.Since = timestamp
+		}
+	}
+
+	for domain, state := range t.states {
+		if state.Fresh && !fresh[domain] {
+			state.Fresh = false
+			state.Since = timestamp
+		}
+	}
+}
+
+// Domains returns every domain seen so far and the well-known ones, sorted
+// by name.
+func (t *DomainTracker) Domains() []DomainState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	states := []DomainState{}
+	for _, state := range t.states {
+		states = append(states, *state)
+	}
+
+	sort.Sort(DomainStatesByDomain(states))
+	return states
+}
+
+// Stale returns the well-known domains that are not fresh.
+func (t *DomainTracker) Stale() []DomainState {
+	stale := []DomainState{}
+	for _, state := range t.Domains() {
+		if state.WellKnown && !state.Fresh {
+			stale = append(stale, state)
+		}
+	}
+	return stale
+}
+
+type DomainStatesByDomain []DomainState
+
+func (l DomainStatesByDomain) Len() int           { return len(l) }
+func (l DomainStatesByDomain) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l DomainStatesByDomain) Less(i, j int) bool { return l[i].Domain < l[j].Domain }
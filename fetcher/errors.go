@@ -0,0 +1,71 @@
+package fetcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FetchError tells which parts of a fetch failed.
+type FetchError struct {
+	Domains error
+	Tasks   error
+	Cells   error
+	LRPs    error
+	// Metrics failing leaves the LRPs without container metrics, usually
+	// because the OAuth token expired or was rejected.
+	Metrics error
+}
+
+func (e *FetchError) Error() string {
+	messages := []string{}
+	if e.Domains != nil {
+		messages = append(messages, fmt.Sprintf("fetching domains: %s", e.Domains))
+	}
+	if e.Tasks != nil {
+		messages = append(messages, fmt.Sprintf("fetching tasks: %s", e.Tasks))
+	}
+	if e.Cells != nil {
+		messages = append(messages, fmt.Sprintf("fetching cells: %s", e.Cells))
+	}
+	if e.LRPs != nil {
+		messages = append(messages, fmt.Sprintf("fetching LRPs: %s", e.LRPs))
+	}
+	if e.Metrics != nil {
+		messages = append(messages, fmt.Sprintf("fetching metrics: %s", e.Metrics))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// orNil returns nil unless some part failed, so that a FetchError is only
+// ever returned for an actual failure.
+func (e FetchError) orNil() error {
+	if e.Domains == nil && e.Tasks == nil && e.Cells == nil && e.LRPs == nil && e.Metrics == nil {
+		return nil
+	}
+	return &e
+}
+
+// KeepPrevious fills the parts of data that failed to fetch from previous.
+func (e *FetchError) KeepPrevious(data *Data, previous *Data) {
+	if previous == nil {
+		return
+	}
+
+	if e.Domains != nil {
+		data.Domains = previous.Domains
+	}
+	if e.Tasks != nil {
+		data.Tasks = previous.Tasks
+	}
+	if e.Cells != nil {
+		data.Cells = previous.Cells
+	}
+	if e.LRPs != nil {
+		// the data is only as recent as the LRPs
+		data.Timestamp = previous.Timestamp
+		data.LRPs = previous.LRPs
+		data.Orphans = previous.Orphans
+	} else if e.Metrics != nil {
+		data.LRPs.keepMetrics(previous.LRPs)
+	}
+}
@@ -0,0 +1,76 @@
+package fetcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+func TestFetchErrorOrNil(t *testing.T) {
+	if err := (FetchError{}).orNil(); err != nil {
+		t.Errorf("expected nil, got %s", err)
+	}
+
+	err := FetchError{Metrics: errors.New("no token")}.orNil()
+	if err == nil || err.Error() != "fetching metrics: no token" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestFetchErrorMessage(t *testing.T) {
+	err := &FetchError{Tasks: errors.New("a"), LRPs: errors.New("b")}
+	if err.Error() != "fetching tasks: a; fetching LRPs: b" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestResynced(t *testing.T) {
+	cases := []struct {
+		err      error
+		resynced bool
+	}{
+		{nil, true},
+		{errors.New("connection refused"), false},
+		{&FetchError{Metrics: errors.New("no token")}, true},
+		{&FetchError{Domains: errors.New("a"), Tasks: errors.New("b"), Cells: errors.New("c")}, true},
+		{&FetchError{LRPs: errors.New("timeout")}, false},
+	}
+	for _, c := range cases {
+		if resynced(c.err) != c.resynced {
+			t.Errorf("resynced(%v): expected %t", c.err, c.resynced)
+		}
+	}
+}
+
+func TestKeepPrevious(t *testing.T) {
+	before := time.Unix(100, 0)
+	previous := &Data{
+		Timestamp: before,
+		Domains:   []string{"cf-apps"},
+		Tasks:     Tasks{&models.Task{TaskGuid: "old-task"}},
+		LRPs:      LRPs{"old": &LRP{}},
+	}
+
+	data := Data{Timestamp: time.Unix(200, 0), LRPs: LRPs{}}
+	(&FetchError{Domains: errors.New("a"), LRPs: errors.New("b")}).KeepPrevious(&data, previous)
+	if len(data.Domains) != 1 || data.LRPs["old"] == nil {
+		t.Error("failed parts were not kept")
+	}
+	if data.Tasks != nil {
+		t.Error("parts that were fetched were replaced")
+	}
+	if !data.Timestamp.Equal(before) {
+		t.Errorf("expected the timestamp of the kept LRPs, got %s", data.Timestamp)
+	}
+
+	data = Data{Timestamp: time.Unix(200, 0)}
+	(&FetchError{Tasks: errors.New("a")}).KeepPrevious(&data, previous)
+	if !data.Timestamp.Equal(time.Unix(200, 0)) {
+		t.Error("timestamp was kept although the LRPs were fetched")
+	}
+
+	// nothing to keep before the first fetch
+	(&FetchError{LRPs: errors.New("a")}).KeepPrevious(&data, nil)
+}
@@ -1,9 +1,11 @@
 package fetcher
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/bbs"
 	"github.com/cloudfoundry-incubator/bbs/models"
@@ -17,103 +19,124 @@ type Fetcher interface {
 type fetcher struct {
 	bbsClient  bbs.Client
 	noaaClient *noaa.Consumer
+	token      TokenFunc
 }
 
 type Data struct {
-	Domains []string
-	Tasks   []*models.Task
-	LRPs    LRPs
+	Timestamp time.Time
+	Domains   []string
+	Tasks     Tasks
+	LRPs      LRPs
+	Orphans   Orphans
+	// Cells are the presences cells advertise to the BBS, so that cells
+	// with nothing placed on them are known too.
+	Cells []*models.CellPresence
 }
 
-func NewFetcher(bbsClient bbs.Client, noaaClient *noaa.Consumer) Fetcher {
+func NewFetcher(bbsClient bbs.Client, noaaClient *noaa.Consumer, token TokenFunc) Fetcher {
 	return &fetcher{
 		bbsClient:  bbsClient,
 		noaaClient: noaaClient,
+		token:      token,
 	}
 }
 
+// Fetch returns a *FetchError if any part of the data could not be fetched,
+// along with every part that could.
 func (f *fetcher) Fetch() (Data, error) {
-	domains, err := f.bbsClient.Domains()
-	if err != nil {
-		return Data{}, err
+	data := Data{Timestamp: time.Now()}
+	fetchErr := FetchError{}
+
+	data.Domains, fetchErr.Domains = f.bbsClient.Domains()
+	data.Tasks, fetchErr.Tasks = f.bbsClient.Tasks()
+	data.Cells, fetchErr.Cells = f.bbsClient.Cells()
+	data.LRPs, data.Orphans, fetchErr.LRPs = f.fetchLRPs()
+	if fetchErr.LRPs == nil {
+		var metrics instanceMetrics
+		metrics, fetchErr.Metrics = f.fetchMetrics(data.LRPs)
+		data.LRPs.applyMetrics(metrics)
 	}
 
-	tasks, err := f.bbsClient.Tasks()
-	if err != nil {
-		return Data{}, err
-	}
-
-	lrps, err := f.fetchLRPs()
-	if err != nil {
-		return Data{}, err
-	}
-
-	return Data{
-		Domains: domains,
-		Tasks:   tasks,
-		LRPs:    lrps,
-	}, nil
+	return data, fetchErr.orNil()
 }
 
-func (f *fetcher) fetchLRPs() (map[string]*LRP, error) {
-	lrps := map[string]*LRP{}
+func (f *fetcher) fetchLRPs() (LRPs, Orphans, error) {
+	lrps := LRPs{}
+	orphans := Orphans{}
 
 	desiredLRPs, err := f.bbsClient.DesiredLRPs(models.DesiredLRPFilter{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, desiredLRP := range desiredLRPs {
-		lrps[desiredLRP.ProcessGuid] = &LRP{Desired: desiredLRP}
+		lrps.desire(desiredLRP)
 	}
 
 	actualLRPGroups, err := f.bbsClient.ActualLRPGroups(models.ActualLRPFilter{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, actualLRPGroup := range actualLRPGroups {
-		actualLRP, evacuating := actualLRPGroup.Resolve()
-
-		lrp, ok := lrps[actualLRP.ProcessGuid]
-		if !ok {
-			continue
+		if !lrps.setActual(actualLRPGroup) {
+			orphans = orphans.set(actualLRPGroup)
 		}
+	}
 
-		actual := Actual{ActualLRP: actualLRP, Evacuating: evacuating}
-		lrp.Actuals = append(lrp.Actuals, &actual)
+	return lrps, orphans, nil
+}
+
+type instanceMetrics map[string]map[int32]ContainerMetrics
+
+// fetchMetrics returns the metrics of every app it could get them for and
+// the first error, if any.
+func (f *fetcher) fetchMetrics(lrps LRPs) (instanceMetrics, error) {
+	lock := sync.Mutex{}
+	metrics := instanceMetrics{}
+	var firstErr error
+
+	authToken, err := f.token()
+	if err != nil {
+		return metrics, fmt.Errorf("getting an OAuth token: %s", err)
 	}
 
 	wg := sync.WaitGroup{}
-	authToken := os.Getenv("OAUTH_TOKEN")
-	for _, lrp := range lrps {
-		lrp := lrp
+	for processGuid, lrp := range lrps {
+		processGuid, logGuid := processGuid, lrp.Desired.LogGuid
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			containerMetrics, err := f.noaaClient.ContainerMetrics(lrp.Desired.LogGuid, authToken)
+			containerMetrics, err := f.noaaClient.ContainerMetrics(logGuid, authToken)
 			if err != nil {
+				if err == noaa.ErrNotAuthorized {
+					err = errors.New("the OAuth token was rejected, it may have expired")
+				}
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				lock.Unlock()
 				return
 			}
 
+			byIndex := map[int32]ContainerMetrics{}
 			for _, metrics := range containerMetrics {
-				for _, actual := range lrp.Actuals {
-					if actual.ActualLRP.Index == metrics.GetInstanceIndex() {
-						containerMetrics := ContainerMetrics{
-							CPU:    metrics.GetCpuPercentage(),
-							Memory: metrics.GetMemoryBytes(),
-							Disk:   metrics.GetDiskBytes(),
-						}
-
-						actual.Metrics = containerMetrics
-					}
+				byIndex[metrics.GetInstanceIndex()] = ContainerMetrics{
+					CPU:    metrics.GetCpuPercentage(),
+					Memory: metrics.GetMemoryBytes(),
+					Disk:   metrics.GetDiskBytes(),
 				}
 			}
+
+			lock.Lock()
+			metrics[processGuid] = byIndex
+			lock.Unlock()
 		}()
 	}
 	wg.Wait()
 
-	return lrps, nil
+	return metrics, firstErr
 }
 
 type CellState struct {
@@ -129,11 +152,63 @@ type CellState struct {
 	NumTasks uint64
 
 	CellId string
+	Zone   string
+
+	// The capacity the cell advertises, zero if it has no presence.
+	MemoryCapacity    uint64
+	DiskCapacity      uint64
+	ContainerCapacity uint64
+	RootfsProviders   []string
+}
+
+// MemoryFree is the advertised memory not yet reserved.
+func (c *CellState) MemoryFree() uint64 {
+	return headroom(c.MemoryCapacity, c.MemoryReserved)
+}
+
+// DiskFree is the advertised disk not yet reserved.
+func (c *CellState) DiskFree() uint64 {
+	return headroom(c.DiskCapacity, c.DiskReserved)
+}
+
+// ContainersFree is how many more LRPs and tasks the cell can run.
+func (c *CellState) ContainersFree() uint64 {
+	return headroom(c.ContainerCapacity, c.NumLRPs+c.NumTasks)
+}
+
+// Megabytes converts the MB of BBS memory and disk limits, which Diego
+// treats as MiB, to bytes.
+func Megabytes(mb int32) uint64 {
+	return uint64(mb) * 1024 * 1024
+}
+
+func headroom(capacity, used uint64) uint64 {
+	if used >= capacity {
+		return 0
+	}
+	return capacity - used
 }
 
 func (d *Data) GetCellState() CellStates {
 	cellStates := map[string]*CellState{}
 
+	for _, presence := range d.Cells {
+		cellState := &CellState{
+			CellId: presence.CellId,
+			Zone:   presence.Zone,
+		}
+		if presence.Capacity != nil {
+			cellState.MemoryCapacity = Megabytes(presence.Capacity.MemoryMb)
+			cellState.DiskCapacity = Megabytes(presence.Capacity.DiskMb)
+			cellState.ContainerCapacity = uint64(presence.Capacity.Containers)
+		}
+		for _, provider := range presence.RootfsProviders {
+			cellState.RootfsProviders = append(cellState.RootfsProviders, provider.Name)
+		}
+		sort.Strings(cellState.RootfsProviders)
+		cellStates[cellState.CellId] = cellState
+	}
+
 	for _, lrp := range d.LRPs {
 		for _, actual := range lrp.Actuals {
 			if actual.ActualLRP.CellId != "" {
@@ -146,13 +221,25 @@ func (d *Data) GetCellState() CellStates {
 				cellState.NumLRPs++
 				cellState.CPUPercentage += actual.Metrics.CPU
 				cellState.MemoryUsed += actual.Metrics.Memory
-				cellState.MemoryReserved += uint64(lrp.Desired.MemoryMb * 1024 * 1024)
+				cellState.MemoryReserved += Megabytes(lrp.Desired.MemoryMb)
 				cellState.DiskUsed += actual.Metrics.Disk
-				cellState.DiskReserved += uint64(lrp.Desired.DiskMb * 1024 * 1024)
+				cellState.DiskReserved += Megabytes(lrp.Desired.DiskMb)
 			}
 		}
 	}
 
+	for _, actual := range d.Orphans {
+		if actual.ActualLRP.CellId != "" {
+			cellState, ok := cellStates[actual.ActualLRP.CellId]
+			if !ok {
+				cellState = &CellState{CellId: actual.ActualLRP.CellId}
+				cellStates[cellState.CellId] = cellState
+			}
+
+			cellState.NumLRPs++
+		}
+	}
+
 	for _, task := range d.Tasks {
 		if task.CellId != "" {
 			cellState, ok := cellStates[task.CellId]
@@ -162,12 +249,55 @@ func (d *Data) GetCellState() CellStates {
 			}
 
 			cellState.NumTasks++
+			cellState.MemoryReserved += Megabytes(task.MemoryMb)
+			cellState.DiskReserved += Megabytes(task.DiskMb)
+		}
+	}
+
+	for _, cellState := range cellStates {
+		if cellState.Zone == "" {
+			cellState.Zone = CellZone(cellState.CellId)
 		}
 	}
 
 	return cellStates
 }
 
+// LRPsOnCell returns the LRPs with instances on the given cell, each holding
+// only the instances placed on it.
+func (d *Data) LRPsOnCell(cellId string) LRPs {
+	lrps := LRPs{}
+
+	for processGuid, lrp := range d.LRPs {
+		for _, actual := range lrp.Actuals {
+			if actual.ActualLRP.CellId != cellId {
+				continue
+			}
+
+			onCell, ok := lrps[processGuid]
+			if !ok {
+				onCell = &LRP{Desired: lrp.Desired}
+				lrps[processGuid] = onCell
+			}
+			onCell.Actuals = append(onCell.Actuals, actual)
+		}
+	}
+
+	return lrps
+}
+
+func (d *Data) TasksOnCell(cellId string) Tasks {
+	tasks := Tasks{}
+
+	for _, task := range d.Tasks {
+		if task.CellId == cellId {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks
+}
+
 type CellStates map[string]*CellState
 
 func (l CellStates) SortedByCellId() []*CellState {
@@ -181,6 +311,25 @@ func (l CellStates) SortedByCellId() []*CellState {
 	return cellStates
 }
 
+func (l CellStates) Total() CellState {
+	total := CellState{}
+
+	for _, state := range l {
+		total.MemoryUsed += state.MemoryUsed
+		total.MemoryReserved += state.MemoryReserved
+		total.DiskUsed += state.DiskUsed
+		total.DiskReserved += state.DiskReserved
+		total.CPUPercentage += state.CPUPercentage
+		total.NumLRPs += state.NumLRPs
+		total.NumTasks += state.NumTasks
+		total.MemoryCapacity += state.MemoryCapacity
+		total.DiskCapacity += state.DiskCapacity
+		total.ContainerCapacity += state.ContainerCapacity
+	}
+
+	return total
+}
+
 func ByCellId(cellStates []*CellState) CellStatesByCellId {
 	return cellStates
 }
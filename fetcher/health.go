@@ -0,0 +1,79 @@
+package fetcher
+
+// Health compares the instances desired for an LRP with its actual LRPs.
+// Only one half of an evacuation is counted, the same one the BBS would
+// resolve the group to.
+type Health struct {
+	Desired   int32
+	Running   int
+	Claimed   int
+	Unclaimed int
+	Crashed   int
+
+	MaxCrashCount int32
+}
+
+func (l *LRP) Health() Health {
+	health := Health{Desired: l.Desired.Instances}
+
+	for _, actual := range l.Actuals {
+		if !l.resolves(actual) {
+			continue
+		}
+
+		switch actual.ActualLRP.State {
+		case "RUNNING":
+			health.Running++
+		case "CLAIMED":
+			health.Claimed++
+		case "UNCLAIMED":
+			health.Unclaimed++
+		case "CRASHED":
+			health.Crashed++
+		}
+
+		if actual.ActualLRP.CrashCount > health.MaxCrashCount {
+			health.MaxCrashCount = actual.ActualLRP.CrashCount
+		}
+	}
+
+	return health
+}
+
+// resolves is false for the half of an evacuation that the BBS hides: the
+// evacuating instance once its replacement is running or has crashed, and
+// the replacement until then.
+func (l *LRP) resolves(actual *Actual) bool {
+	counterpart := l.Counterpart(actual)
+	if counterpart == nil {
+		return true
+	}
+
+	instance := actual
+	if actual.Evacuating {
+		instance = counterpart
+	}
+	replaced := instance.ActualLRP.State == "RUNNING" || instance.ActualLRP.State == "CRASHED"
+	return replaced != actual.Evacuating
+}
+
+// Starting counts the instances that are placed but not running yet.
+func (h Health) Starting() int {
+	return h.Claimed + h.Unclaimed
+}
+
+// Healthy is true when exactly the desired number of instances is running.
+func (h Health) Healthy() bool {
+	return h.Running == int(h.Desired) && h.Crashed == 0 && h.Starting() == 0
+}
+
+// Unhealthy returns the LRPs whose health is off.
+func (l LRPs) Unhealthy() LRPs {
+	unhealthy := LRPs{}
+	for processGuid, lrp := range l {
+		if !lrp.Health().Healthy() {
+			unhealthy[processGuid] = lrp
+		}
+	}
+	return unhealthy
+}
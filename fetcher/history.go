@@ -0,0 +1,140 @@
+package fetcher
+
+import (
+	"sync"
+	"time"
+)
+
+type InstanceKey struct {
+	ProcessGuid  string
+	Index        int32
+	InstanceGuid string
+}
+
+func NewInstanceKey(actual *Actual) InstanceKey {
+	return InstanceKey{
+		ProcessGuid:  actual.ActualLRP.ProcessGuid,
+		Index:        actual.ActualLRP.Index,
+		InstanceGuid: actual.ActualLRP.InstanceGuid,
+	}
+}
+
+// History keeps the last few metric samples of every instance and cell.
+// Instances and cells that disappear are forgotten.
+type History struct {
+	size     int
+	interval time.Duration
+
+	lock       sync.Mutex
+	recordedAt time.Time
+	instances  map[InstanceKey]*ring
+	cells      map[string]*ring
+}
+
+// NewHistory keeps size samples per instance and cell, taken at most once
+// every interval.
+func NewHistory(size int, interval time.Duration) *History {
+	return &History{
+		size:      size,
+		interval:  interval,
+		instances: map[InstanceKey]*ring{},
+		cells:     map[string]*ring{},
+	}
+}
+
+func (h *History) Record(data *Data) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	timestamp := data.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if timestamp.Sub(h.recordedAt) < h.interval && !timestamp.Before(h.recordedAt) {
+		return
+	}
+	h.recordedAt = timestamp
+
+	instances := map[InstanceKey]*ring{}
+	for _, lrp := range data.LRPs {
+		for _, actual := range lrp.Actuals {
+			key := NewInstanceKey(actual)
+			r, ok := h.instances[key]
+			if !ok {
+				r = newRing(h.size)
+			}
+			r.add(actual.Metrics)
+			instances[key] = r
+		}
+	}
+	h.instances = instances
+
+	cells := map[string]*ring{}
+	for cellId, cell := range data.GetCellState() {
+		r, ok := h.cells[cellId]
+		if !ok {
+			r = newRing(h.size)
+		}
+		r.add(ContainerMetrics{
+			CPU:    cell.CPUPercentage,
+			Memory: cell.MemoryUsed,
+			Disk:   cell.DiskUsed,
+		})
+		cells[cellId] = r
+	}
+	h.cells = cells
+}
+
+// Instance returns the samples of an instance, oldest first.
+func (h *History) Instance(actual *Actual) []ContainerMetrics {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	r, ok := h.instances[NewInstanceKey(actual)]
+	if !ok {
+		return nil
+	}
+	return r.values()
+}
+
+// Cell returns the summed samples of a cell, oldest first.
+func (h *History) Cell(cellId string) []ContainerMetrics {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	r, ok := h.cells[cellId]
+	if !ok {
+		return nil
+	}
+	return r.values()
+}
+
+type ring struct {
+	samples []ContainerMetrics
+	next    int
+	full    bool
+}
+
+func newRing(size int) *ring {
+	return &ring{samples: make([]ContainerMetrics, size)}
+}
+
+func (r *ring) add(sample ContainerMetrics) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *ring) values() []ContainerMetrics {
+	if !r.full {
+		values := make([]ContainerMetrics, r.next)
+		copy(values, r.samples[:r.next])
+		return values
+	}
+
+	values := make([]ContainerMetrics, 0, len(r.samples))
+	values = append(values, r.samples[r.next:]...)
+	return append(values, r.samples[:r.next]...)
+}
@@ -0,0 +1,96 @@
+package fetcher
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/noaa"
+	"github.com/cloudfoundry/sonde-go/events"
+)
+
+// LogTailer streams the logs of a single app at a time. Every tail gets its
+// own consumer since closing a consumer is the only way to stop tailing.
+type LogTailer struct {
+	newConsumer func() (*noaa.Consumer, error)
+	token       TokenFunc
+
+	lock     sync.Mutex
+	consumer *noaa.Consumer
+	done     chan struct{}
+}
+
+func NewLogTailer(newConsumer func() (*noaa.Consumer, error), token TokenFunc) *LogTailer {
+	return &LogTailer{newConsumer: newConsumer, token: token}
+}
+
+// Tail stops the previous tail and calls handler with every log message of
+// logGuid until Stop is called.
+func (t *LogTailer) Tail(logGuid string, handler func(*events.LogMessage), errorHandler func(error)) error {
+	t.Stop()
+
+	authToken, err := t.token()
+	if err != nil {
+		return err
+	}
+
+	consumer, err := t.newConsumer()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	t.lock.Lock()
+	t.consumer = consumer
+	t.done = done
+	t.lock.Unlock()
+
+	messages := make(chan *events.LogMessage)
+	errs := make(chan error)
+	finished := make(chan struct{})
+	go func() {
+		consumer.TailingLogs(logGuid, authToken, messages, errs)
+		close(finished)
+	}()
+
+	// keep draining after Stop so that TailingLogs is never stuck sending
+	go func() {
+		for {
+			select {
+			case message := <-messages:
+				if !isDone(done) {
+					handler(message)
+				}
+			case err := <-errs:
+				if !isDone(done) {
+					errorHandler(err)
+				}
+			case <-finished:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (t *LogTailer) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.consumer == nil {
+		return
+	}
+
+	close(t.done)
+	t.consumer.Close()
+	t.consumer = nil
+	t.done = nil
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,74 @@
+package fetcher
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+// Orphans are actual LRPs whose process guid has no desired LRP, usually
+// left behind by a failed deploy or a leaking cell.
+type Orphans []*Actual
+
+func (o Orphans) set(actualLRPGroup *models.ActualLRPGroup) Orphans {
+	return setActualGroup(o, actualLRPGroup)
+}
+
+func (o Orphans) remove(actualLRPGroup *models.ActualLRPGroup) Orphans {
+	return removeActualGroup(o, actualLRPGroup)
+}
+
+// adopt moves the orphans of a newly desired LRP into it and returns the
+// remaining ones.
+func (o Orphans) adopt(lrp *LRP) Orphans {
+	remaining := Orphans{}
+	for _, actual := range o {
+		if actual.ActualLRP.ProcessGuid == lrp.Desired.ProcessGuid {
+			lrp.Actuals = append(lrp.Actuals, actual)
+		} else {
+			remaining = append(remaining, actual)
+		}
+	}
+	return remaining
+}
+
+// Counterpart is like LRP.Counterpart for orphans.
+func (o Orphans) Counterpart(actual *Actual) *Actual {
+	for _, other := range o {
+		if other.ActualLRP.ProcessGuid == actual.ActualLRP.ProcessGuid &&
+			other.ActualLRP.Index == actual.ActualLRP.Index &&
+			other.Evacuating != actual.Evacuating {
+			return other
+		}
+	}
+	return nil
+}
+
+func (o Orphans) OnCell(cellId string) Orphans {
+	orphans := Orphans{}
+	for _, actual := range o {
+		if actual.ActualLRP.CellId == cellId {
+			orphans = append(orphans, actual)
+		}
+	}
+	return orphans
+}
+
+func (o Orphans) SortedByProcessGuid() []*Actual {
+	actuals := make([]*Actual, len(o))
+	copy(actuals, o)
+
+	sort.Sort(OrphansByProcessGuid(actuals))
+	return actuals
+}
+
+type OrphansByProcessGuid []*Actual
+
+func (l OrphansByProcessGuid) Len() int      { return len(l) }
+func (l OrphansByProcessGuid) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l OrphansByProcessGuid) Less(i, j int) bool {
+	if l[i].ActualLRP.ProcessGuid == l[j].ActualLRP.ProcessGuid {
+		return l[i].ActualLRP.Index < l[j].ActualLRP.Index
+	}
+	return l[i].ActualLRP.ProcessGuid < l[j].ActualLRP.ProcessGuid
+}
@@ -0,0 +1,148 @@
+package fetcher
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// frame is a single recorded snapshot. Recordings are a gzip stream of
+// newline separated JSON frames; every session appends a new gzip member
+// so the file can be reopened and appended to.
+type frame struct {
+	Timestamp time.Time `json:"timestamp"`
+	Data      Data      `json:"data"`
+}
+
+type Recorder struct {
+	lock    sync.Mutex
+	file    *os.File
+	writer  *gzip.Writer
+	encoder *json.Encoder
+
+	recordedAt time.Time
+}
+
+// NewRecorder appends to the recording at path, unless it ends in a session
+// that was cut short: a new session after it could never be read back.
+func NewRecorder(path string) (*Recorder, error) {
+	err := checkRecording(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := gzip.NewWriter(file)
+	return &Recorder{
+		file:    file,
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}, nil
+}
+
+// Record appends a snapshot and flushes it, so that a recording cut short
+// by a crash still contains every frame up to that point. Snapshots no newer
+// than the last one recorded, e.g. stale data kept while the BBS fails, are
+// skipped.
+func (r *Recorder) Record(timestamp time.Time, data *Data) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !timestamp.After(r.recordedAt) {
+		return nil
+	}
+	r.recordedAt = timestamp
+
+	err := r.encoder.Encode(frame{Timestamp: timestamp, Data: *data})
+	if err != nil {
+		return err
+	}
+	return r.writer.Flush()
+}
+
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	err := r.writer.Close()
+	if err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// checkRecording fails if the recording at path, if any, does not end
+// cleanly.
+func checkRecording(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	reader, err := gzip.NewReader(bufio.NewReader(file))
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, reader)
+	}
+	if err != nil {
+		return fmt.Errorf("%s ends in a session that was cut short (%s), record to a new file", path, err)
+	}
+	return nil
+}
+
+// readFrames returns every frame it can read. A session that was cut short
+// is only a problem if more sessions were appended after it, which cannot
+// be read; damaged then tells how many frames were read before it.
+func readFrames(path string) (frames []frame, damaged error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	frames = []frame{}
+	decoder := json.NewDecoder(reader)
+	for {
+		var f frame
+		err := decoder.Decode(&f)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// the tail of a recording that was not closed cleanly is
+			// truncated; keep every complete frame before it
+			break
+		}
+		if err != nil {
+			if len(frames) == 0 {
+				return nil, nil, err
+			}
+			damaged = fmt.Errorf("recording is damaged after frame %d, the rest cannot be read: %s", len(frames), err)
+			break
+		}
+		frames = append(frames, f)
+	}
+
+	return frames, damaged, nil
+}
@@ -0,0 +1,178 @@
+package fetcher
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordingRoundTrip(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	start := time.Unix(1000, 0)
+
+	record(t, path, true, start, start.Add(time.Second))
+	record(t, path, true, start.Add(2*time.Second))
+
+	frames, damaged, err := readFrames(path)
+	if err != nil || damaged != nil {
+		t.Fatalf("reading the recording: %v, %v", err, damaged)
+	}
+	expectTimestamps(t, frames, start, start.Add(time.Second), start.Add(2*time.Second))
+}
+
+func TestRecorderSkipsStaleSnapshots(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	start := time.Unix(1000, 0)
+
+	record(t, path, true, start, start, start.Add(-time.Second), start.Add(time.Second))
+
+	frames, _, err := readFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectTimestamps(t, frames, start, start.Add(time.Second))
+}
+
+func TestCutShortRecording(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	start := time.Unix(1000, 0)
+
+	record(t, path, false, start, start.Add(time.Second))
+
+	frames, damaged, err := readFrames(path)
+	if err != nil || damaged != nil {
+		t.Fatalf("reading the recording: %v, %v", err, damaged)
+	}
+	expectTimestamps(t, frames, start, start.Add(time.Second))
+
+	_, err = NewRecorder(path)
+	if err == nil {
+		t.Error("appending after a session that was cut short did not fail")
+	}
+}
+
+func TestDamagedRecording(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	start := time.Unix(1000, 0)
+
+	record(t, path, false, start, start.Add(time.Second))
+
+	// a session appended without checking, as older versions did
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := gzip.NewWriter(file)
+	json.NewEncoder(writer).Encode(frame{Timestamp: start.Add(time.Hour)})
+	writer.Close()
+	file.Close()
+
+	frames, damaged, err := readFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if damaged == nil {
+		t.Error("the damage was not reported")
+	}
+	expectTimestamps(t, frames, start, start.Add(time.Second))
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Damaged() == nil {
+		t.Error("the replayer did not report the damage")
+	}
+}
+
+func TestEmptyRecording(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	record(t, path, true)
+
+	_, err := NewReplayer(path)
+	if err == nil {
+		t.Error("replaying a recording without frames did not fail")
+	}
+}
+
+func TestReplayerStepsThroughFrames(t *testing.T) {
+	path := recordingPath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	start := time.Unix(1000, 0)
+	record(t, path, true, start, start.Add(time.Hour), start.Add(2*time.Hour))
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := replayer.Fetch()
+	if err != nil || !data.Timestamp.Equal(start) {
+		t.Fatalf("expected the first frame, got %s, %v", data.Timestamp, err)
+	}
+
+	replayer.Step(2)
+	data, _ = replayer.Fetch()
+	if !data.Timestamp.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("expected the last frame, got %s", data.Timestamp)
+	}
+
+	replayer.Seek(-90 * time.Minute)
+	data, _ = replayer.Fetch()
+	if !data.Timestamp.Equal(start) {
+		t.Errorf("expected the first frame, got %s", data.Timestamp)
+	}
+}
+
+func recordingPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "dope-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "recording.gz")
+}
+
+// record appends a session with a frame at every timestamp, closing it
+// cleanly or leaving it cut short like a crash would.
+func record(t *testing.T, path string, close bool, timestamps ...time.Time) {
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, timestamp := range timestamps {
+		err := recorder.Record(timestamp, &Data{LRPs: LRPs{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if close {
+		err = recorder.Close()
+	} else {
+		err = recorder.file.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectTimestamps(t *testing.T, frames []frame, timestamps ...time.Time) {
+	if len(frames) != len(timestamps) {
+		t.Fatalf("expected %d frames, got %d", len(timestamps), len(frames))
+	}
+	for i, f := range frames {
+		if !f.Timestamp.Equal(timestamps[i]) {
+			t.Errorf("frame %d: expected %s, got %s", i, timestamps[i], f.Timestamp)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Replayer is a Fetcher that plays back a recording made by Recorder. Fetch
+// blocks until the next frame is due, so it can be polled in a tight loop.
+type Replayer struct {
+	frames  []frame
+	damaged error
+
+	lock    sync.Mutex
+	current int
+	shownAt time.Time
+	jumped  bool
+	paused  bool
+	speed   float64
+	wake    chan struct{}
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	frames, damaged, err := readFrames(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, errors.New("recording has no frames: " + path)
+	}
+
+	return &Replayer{
+		frames:  frames,
+		damaged: damaged,
+		current: -1,
+		speed:   1,
+		wake:    make(chan struct{}, 1),
+	}, nil
+}
+
+func (r *Replayer) Fetch() (Data, error) {
+	for {
+		r.lock.Lock()
+		if r.current < 0 || r.jumped {
+			if r.current < 0 {
+				r.current = 0
+			}
+			return r.show(), nil
+		}
+
+		var due <-chan time.Time
+		if !r.paused && r.current < len(r.frames)-1 {
+			gap := r.frames[r.current+1].Timestamp.Sub(r.frames[r.current].Timestamp)
+			wait := time.Duration(float64(gap)/r.speed) - time.Since(r.shownAt)
+			if wait <= 0 {
+				r.current++
+				return r.show(), nil
+			}
+			due = time.After(wait)
+		}
+		r.lock.Unlock()
+
+		select {
+		case <-due:
+		case <-r.wake:
+		}
+	}
+}
+
+// show must be called with the lock held and releases it.
+func (r *Replayer) show() Data {
+	defer r.lock.Unlock()
+
+	r.jumped = false
+	r.shownAt = time.Now()
+
+	data := r.frames[r.current].Data
+	data.Timestamp = r.frames[r.current].Timestamp
+	data.LRPs = data.LRPs.copy()
+	return data
+}
+
+func (r *Replayer) TogglePause() {
+	r.lock.Lock()
+	r.paused = !r.paused
+	r.shownAt = time.Now()
+	r.lock.Unlock()
+	r.notify()
+}
+
+// Step moves the given number of frames forward, or backward when negative.
+func (r *Replayer) Step(frames int) {
+	r.lock.Lock()
+	r.jumpTo(r.current + frames)
+	r.lock.Unlock()
+	r.notify()
+}
+
+// Seek moves to the first frame recorded at least offset after the current
+// one, or the last frame recorded at most -offset before it.
+func (r *Replayer) Seek(offset time.Duration) {
+	r.lock.Lock()
+	defer r.notify()
+	defer r.lock.Unlock()
+
+	if r.current < 0 {
+		return
+	}
+
+	target := r.frames[r.current].Timestamp.Add(offset)
+	index := r.current
+	if offset > 0 {
+		for index < len(r.frames)-1 && r.frames[index].Timestamp.Before(target) {
+			index++
+		}
+	} else {
+		for index > 0 && r.frames[index].Timestamp.After(target) {
+			index--
+		}
+	}
+	r.jumpTo(index)
+}
+
+// SetSpeed multiplies the playback speed by factor.
+func (r *Replayer) SetSpeed(factor float64) {
+	r.lock.Lock()
+	r.speed *= factor
+	if r.speed < 0.125 {
+		r.speed = 0.125
+	} else if r.speed > 64 {
+		r.speed = 64
+	}
+	r.lock.Unlock()
+	r.notify()
+}
+
+// Damaged tells why frames after the last one could not be read, if the
+// recording is damaged.
+func (r *Replayer) Damaged() error {
+	return r.damaged
+}
+
+func (r *Replayer) Status() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.current < 0 {
+		return "replay: loading"
+	}
+
+	status := fmt.Sprintf("replay %d/%d %s x%g",
+		r.current+1, len(r.frames),
+		r.frames[r.current].Timestamp.Local().Format("2006-01-02 15:04:05"),
+		r.speed,
+	)
+	if r.paused {
+		status += " (paused)"
+	}
+	return status
+}
+
+func (r *Replayer) jumpTo(index int) {
+	if index < 0 {
+		index = 0
+	} else if index > len(r.frames)-1 {
+		index = len(r.frames) - 1
+	}
+	r.current = index
+	r.jumped = true
+}
+
+func (r *Replayer) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
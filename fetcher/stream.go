@@ -0,0 +1,307 @@
+package fetcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs"
+	"github.com/cloudfoundry-incubator/bbs/events"
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry/noaa"
+)
+
+// Streamer keeps a Data snapshot up to date from the BBS event stream,
+// falling back to polling whenever the stream cannot be established.
+type Streamer struct {
+	// MetricsInterval is how often container metrics are refreshed.
+	MetricsInterval time.Duration
+	// RefreshInterval is how often tasks, domains and cells, which have
+	// no events, are refetched from the BBS.
+	RefreshInterval time.Duration
+	// PollInterval is how often everything is refetched while the event
+	// stream is down.
+	PollInterval time.Duration
+
+	fetcher *fetcher
+
+	lock sync.Mutex
+	data Data
+}
+
+func NewStreamer(bbsClient bbs.Client, noaaClient *noaa.Consumer, token TokenFunc) *Streamer {
+	return &Streamer{
+		MetricsInterval: time.Second,
+		RefreshInterval: 5 * time.Second,
+		PollInterval:    time.Second,
+		fetcher: &fetcher{
+			bbsClient:  bbsClient,
+			noaaClient: noaaClient,
+			token:      token,
+		},
+	}
+}
+
+// Stream calls handler with a fresh snapshot every time the data changes
+// and errorHandler whenever the error changes, with nil once fetching stops
+// failing. Failed fetches and dropped event streams are retried with
+// backoff and the last data fetched is kept, along with the time it was
+// fetched. Stream never returns.
+func (s *Streamer) Stream(handler func(*Data), errorHandler func(error)) {
+	errorHandler = onChange(errorHandler)
+	backoff := NewBackoff(s.PollInterval, maxBackoff)
+	for {
+		eventSource, err := s.fetcher.bbsClient.SubscribeToEvents()
+		if err != nil {
+			err = s.poll(handler)
+			errorHandler(err)
+			if !resynced(err) {
+				time.Sleep(backoff.Next())
+				continue
+			}
+			backoff.Reset()
+			time.Sleep(s.PollInterval)
+			continue
+		}
+
+		// resync after subscribing so that no event is missed in between
+		err = s.poll(handler)
+		errorHandler(err)
+		if !resynced(err) {
+			eventSource.Close()
+			time.Sleep(backoff.Next())
+			continue
+		}
+
+		// a stream that keeps dropping right after subscribing backs off
+		// like a failing poll, only one that stayed up starts over
+		subscribed := time.Now()
+		err = s.stream(eventSource, err, handler, errorHandler)
+		errorHandler(fmt.Errorf("event stream dropped: %s", err))
+		if time.Since(subscribed) >= maxBackoff {
+			backoff.Reset()
+		}
+		time.Sleep(backoff.Next())
+	}
+}
+
+// maxBackoff caps the delay between retries of a failing BBS.
+const maxBackoff = time.Minute
+
+// onChange only passes errors on when they differ from the previous one.
+func onChange(errorHandler func(error)) func(error) {
+	last := ""
+	return func(err error) {
+		message := ""
+		if err != nil {
+			message = err.Error()
+		}
+		if message == last {
+			return
+		}
+		last = message
+		errorHandler(err)
+	}
+}
+
+// resynced tells whether a poll fetched the LRPs, which events are applied
+// to. Whatever else failed is kept from before and refreshed while
+// streaming.
+func resynced(err error) bool {
+	if fetchErr, ok := err.(*FetchError); ok {
+		return fetchErr.LRPs == nil
+	}
+	return err == nil
+}
+
+// poll fetches everything, keeping the previous data for whatever failed.
+func (s *Streamer) poll(handler func(*Data)) error {
+	data, err := s.fetcher.Fetch()
+
+	s.lock.Lock()
+	if fetchErr, ok := err.(*FetchError); ok {
+		fetchErr.KeepPrevious(&data, &s.data)
+	}
+	if data.LRPs == nil {
+		data.LRPs = LRPs{}
+	}
+	s.data = data
+	s.lock.Unlock()
+
+	handler(s.snapshot())
+	return err
+}
+
+// stream applies events until the event source drops, returning why, so
+// that Stream can resubscribe. pollErr is whatever the resync failed to
+// fetch, which stays reported until it is refreshed.
+func (s *Streamer) stream(eventSource events.EventSource, pollErr error, handler func(*Data), errorHandler func(error)) error {
+	eventChan := make(chan models.Event)
+	errChan := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	defer eventSource.Close()
+
+	go func() {
+		for {
+			event, err := eventSource.Next()
+			if err == events.ErrUnrecognizedEventType {
+				continue
+			}
+			if err != nil {
+				errChan <- err
+				return
+			}
+
+			select {
+			case eventChan <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	metricsTicker := time.NewTicker(s.MetricsInterval)
+	defer metricsTicker.Stop()
+	refreshTicker := time.NewTicker(s.RefreshInterval)
+	defer refreshTicker.Stop()
+
+	// the latest error of every refresh, so that one succeeding does not
+	// hide another failing
+	refreshErr := FetchError{}
+	if fetchErr, ok := pollErr.(*FetchError); ok {
+		refreshErr = *fetchErr
+	}
+	for {
+		// whether the update succeeded, so that a failing refresh does not
+		// pass stale data off as current
+		updated := true
+		select {
+		case event := <-eventChan:
+			s.apply(event)
+			s.drain(eventChan)
+		case err := <-errChan:
+			return err
+		case <-metricsTicker.C:
+			refreshErr.Metrics = s.refreshMetrics()
+			updated = refreshErr.Metrics == nil
+			errorHandler(refreshErr.orNil())
+		case <-refreshTicker.C:
+			refreshErr.Domains, refreshErr.Tasks, refreshErr.Cells = s.refresh()
+			updated = refreshErr.Domains == nil && refreshErr.Tasks == nil && refreshErr.Cells == nil
+			errorHandler(refreshErr.orNil())
+		}
+
+		if updated {
+			s.lock.Lock()
+			s.data.Timestamp = time.Now()
+			s.lock.Unlock()
+		}
+
+		handler(s.snapshot())
+	}
+}
+
+// drain applies any events that are already waiting so that bursts only
+// cause a single update.
+func (s *Streamer) drain(eventChan <-chan models.Event) {
+	for {
+		select {
+		case event := <-eventChan:
+			s.apply(event)
+		default:
+			return
+		}
+	}
+}
+
+func (s *Streamer) apply(event models.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch event := event.(type) {
+	case *models.DesiredLRPCreatedEvent:
+		s.desire(event.DesiredLrp)
+	case *models.DesiredLRPChangedEvent:
+		s.desire(event.After)
+	case *models.DesiredLRPRemovedEvent:
+		processGuid := event.DesiredLrp.ProcessGuid
+		if lrp, ok := s.data.LRPs[processGuid]; ok {
+			// its actuals linger until the BBS removes them
+			s.data.Orphans = append(s.data.Orphans, lrp.Actuals...)
+			delete(s.data.LRPs, processGuid)
+		}
+	case *models.ActualLRPCreatedEvent:
+		s.setActual(event.ActualLrpGroup)
+	case *models.ActualLRPChangedEvent:
+		s.removeActual(vanished(event.Before, event.After))
+		s.setActual(event.After)
+	case *models.ActualLRPRemovedEvent:
+		s.removeActual(event.ActualLrpGroup)
+	}
+}
+
+func (s *Streamer) desire(desiredLRP *models.DesiredLRP) {
+	s.data.LRPs.desire(desiredLRP)
+	s.data.Orphans = s.data.Orphans.adopt(s.data.LRPs[desiredLRP.ProcessGuid])
+}
+
+func (s *Streamer) setActual(actualLRPGroup *models.ActualLRPGroup) {
+	if !s.data.LRPs.setActual(actualLRPGroup) {
+		s.data.Orphans = s.data.Orphans.set(actualLRPGroup)
+	}
+}
+
+func (s *Streamer) removeActual(actualLRPGroup *models.ActualLRPGroup) {
+	s.data.LRPs.removeActual(actualLRPGroup)
+	s.data.Orphans = s.data.Orphans.remove(actualLRPGroup)
+}
+
+func (s *Streamer) refreshMetrics() error {
+	s.lock.Lock()
+	lrps := s.data.LRPs.copy()
+	s.lock.Unlock()
+
+	metrics, err := s.fetcher.fetchMetrics(lrps)
+
+	s.lock.Lock()
+	s.data.LRPs.applyMetrics(metrics)
+	s.lock.Unlock()
+
+	return err
+}
+
+// refresh refetches everything the event stream does not cover.
+func (s *Streamer) refresh() (domainsErr, tasksErr, cellsErr error) {
+	domains, domainsErr := s.fetcher.bbsClient.Domains()
+	tasks, tasksErr := s.fetcher.bbsClient.Tasks()
+	cells, cellsErr := s.fetcher.bbsClient.Cells()
+
+	s.lock.Lock()
+	if domainsErr == nil {
+		s.data.Domains = domains
+	}
+	if tasksErr == nil {
+		s.data.Tasks = tasks
+	}
+	if cellsErr == nil {
+		s.data.Cells = cells
+	}
+	s.lock.Unlock()
+
+	return domainsErr, tasksErr, cellsErr
+}
+
+func (s *Streamer) snapshot() *Data {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return &Data{
+		Timestamp: s.data.Timestamp,
+		Domains:   s.data.Domains,
+		Tasks:     s.data.Tasks,
+		Cells:     s.data.Cells,
+		LRPs:      s.data.LRPs.copy(),
+		Orphans:   copyActuals(s.data.Orphans),
+	}
+}
@@ -0,0 +1,29 @@
+package fetcher
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+type Tasks []*models.Task
+
+func (t Tasks) SortedByTaskGuid() []*models.Task {
+	tasks := make([]*models.Task, len(t))
+	copy(tasks, t)
+
+	sort.Sort(TasksByTaskGuid(tasks))
+	return tasks
+}
+
+type TasksByTaskGuid []*models.Task
+
+func (l TasksByTaskGuid) Len() int      { return len(l) }
+func (l TasksByTaskGuid) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l TasksByTaskGuid) Less(i, j int) bool {
+	return l[i].TaskGuid < l[j].TaskGuid
+}
+
+func TaskCommand(task *models.Task) string {
+	return printAction(task.Action)
+}
@@ -0,0 +1,5 @@
+package fetcher
+
+// TokenFunc returns the OAuth token sent to the traffic controller, ready
+// for the Authorization header.
+type TokenFunc func() (string, error)
@@ -0,0 +1,174 @@
+package fetcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// SplitCellId splits a cell id like cell_z1-0 into its zone and index.
+func SplitCellId(cellId string) (zone, index string, ok bool) {
+	parts := strings.Split(cellId, "_")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	parts = strings.SplitN(parts[1], "-", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// CellZone returns the zone in a cell id like cell_z1-0, or "" if the id
+// does not name one.
+func CellZone(cellId string) string {
+	zone, _, _ := SplitCellId(cellId)
+	return zone
+}
+
+// ZoneState sums the cells of a single zone.
+type ZoneState struct {
+	CellState
+
+	NumCells int
+}
+
+// AverageCPU is the CPU usage per cell, CPUPercentage being the sum over
+// every cell of the zone.
+func (z *ZoneState) AverageCPU() float64 {
+	if z.NumCells == 0 {
+		return 0
+	}
+	return z.CPUPercentage / float64(z.NumCells)
+}
+
+type ZoneStates map[string]*ZoneState
+
+func (l CellStates) ByZone() ZoneStates {
+	cellsByZone := map[string]CellStates{}
+	for cellId, cell := range l {
+		cells, ok := cellsByZone[cell.Zone]
+		if !ok {
+			cells = CellStates{}
+			cellsByZone[cell.Zone] = cells
+		}
+		cells[cellId] = cell
+	}
+
+	zoneStates := ZoneStates{}
+	for zone, cells := range cellsByZone {
+		zoneState := &ZoneState{CellState: cells.Total(), NumCells: len(cells)}
+		zoneState.Zone = zone
+		zoneStates[zone] = zoneState
+	}
+	return zoneStates
+}
+
+func (l ZoneStates) SortedByZone() []*ZoneState {
+	zoneStates := []*ZoneState{}
+	for _, zoneState := range l {
+		zoneStates = append(zoneStates, zoneState)
+	}
+
+	sort.Sort(ZoneStatesByZone(zoneStates))
+	return zoneStates
+}
+
+type ZoneStatesByZone []*ZoneState
+
+func (l ZoneStatesByZone) Len() int           { return len(l) }
+func (l ZoneStatesByZone) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l ZoneStatesByZone) Less(i, j int) bool { return l[i].Zone < l[j].Zone }
+
+// Placement tells how the instances of LRPs are spread across zones.
+type Placement struct {
+	cellZones map[string]string
+	zones     map[string]struct{}
+}
+
+// Placement knows the zones of every cell with a presence and every cell
+// hosting an instance.
+func (d *Data) Placement() *Placement {
+	p := &Placement{
+		cellZones: map[string]string{},
+		zones:     map[string]struct{}{},
+	}
+
+	for _, presence := range d.Cells {
+		zone := presence.Zone
+		if zone == "" {
+			zone = CellZone(presence.CellId)
+		}
+		p.cellZones[presence.CellId] = zone
+		p.zones[zone] = struct{}{}
+	}
+
+	for _, lrp := range d.LRPs {
+		for _, actual := range lrp.Actuals {
+			p.zone(actual.ActualLRP.CellId)
+		}
+	}
+
+	return p
+}
+
+func (p *Placement) zone(cellId string) string {
+	zone, ok := p.cellZones[cellId]
+	if !ok {
+		zone = CellZone(cellId)
+		p.cellZones[cellId] = zone
+		if cellId != "" {
+			p.zones[zone] = struct{}{}
+		}
+	}
+	return zone
+}
+
+// Zones returns every zone cells were seen in.
+func (p *Placement) Zones() []string {
+	zones := []string{}
+	for zone := range p.zones {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// Distribution counts the placed instances of an LRP in every zone. Only
+// the half of an evacuation that the BBS resolves the group to is counted.
+func (p *Placement) Distribution(lrp *LRP) map[string]int {
+	distribution := map[string]int{}
+	for _, actual := range lrp.Actuals {
+		if actual.ActualLRP.CellId == "" || !lrp.resolves(actual) {
+			continue
+		}
+		distribution[p.zone(actual.ActualLRP.CellId)]++
+	}
+	return distribution
+}
+
+// SingleZone is true for an LRP with more than one instance placed, all of
+// them in the same zone, while there are cells in other zones it could
+// have been spread across.
+func (p *Placement) SingleZone(lrp *LRP) bool {
+	distribution := p.Distribution(lrp)
+	if len(distribution) != 1 || len(p.zones) < 2 {
+		return false
+	}
+
+	for _, instances := range distribution {
+		return instances > 1
+	}
+	return false
+}
+
+// SingleZoneLRPs returns the LRPs that would lose every instance to a single
+// zone going down.
+func (p *Placement) SingleZoneLRPs(lrps LRPs) []*LRP {
+	singleZone := []*LRP{}
+	for _, lrp := range lrps.SortedByProcessGuid() {
+		if p.SingleZone(lrp) {
+			singleZone = append(singleZone, lrp)
+		}
+	}
+	return singleZone
+}
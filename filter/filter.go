@@ -0,0 +1,459 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/luan/dope/cloud_controller"
+	"github.com/luan/dope/fetcher"
+)
+
+// Filter narrows down LRPs, instances, tasks and cells. It is built from a
+// space separated list of terms which must all match:
+//
+//	abc          process or task guid starts with abc
+//	guid:abc     same as above
+//	domain:cf    domain starts with cf
+//	state:crash  instance or task state contains crash
+//	cell:z1      cell id contains z1
+//	route:foo    a cf-router hostname contains foo
+//	app:web      Cloud Controller app name contains web
+//	space:dev    Cloud Controller space name contains dev
+//	org:acme     Cloud Controller org name contains acme
+//	cpu>80       instance cpu is above 80%
+//	memory>=1GB  instance memory usage is at least 1GB
+//	disk<90%     instance disk usage is below 90% of its limit
+//
+// Prefixing a term with ! negates it. The app, space and org terms only
+// match once SetNames gave the filter a way to look names up.
+type Filter struct {
+	expression string
+	terms      []term
+	names      Names
+}
+
+// Names looks up the Cloud Controller names of a process guid.
+type Names interface {
+	Lookup(processGuid string) (cloud_controller.App, bool)
+}
+
+func (f *Filter) SetNames(names Names) {
+	if f != nil {
+		f.names = names
+	}
+}
+
+type term struct {
+	field  string
+	op     string
+	value  string
+	negate bool
+
+	threshold float64
+	relative  bool
+}
+
+var fields = map[string]bool{
+	"guid":   true,
+	"domain": true,
+	"state":  true,
+	"cell":   true,
+	"route":  true,
+	"app":    true,
+	"space":  true,
+	"org":    true,
+	"cpu":    true,
+	"memory": true,
+	"disk":   true,
+}
+
+var comparisons = []string{">=", "<=", ">", "<"}
+
+func Parse(expression string) (*Filter, error) {
+	f := &Filter{expression: strings.TrimSpace(expression)}
+
+	for _, word := range strings.Fields(expression) {
+		t, err := parseTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, t)
+	}
+
+	return f, nil
+}
+
+func parseTerm(word string) (term, error) {
+	t := term{}
+	if strings.HasPrefix(word, "!") {
+		t.negate = true
+		word = word[1:]
+	}
+
+	for _, op := range comparisons {
+		parts := strings.SplitN(word, op, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		t.field, t.op, t.value = strings.ToLower(parts[0]), op, parts[1]
+		if t.field != "cpu" && t.field != "memory" && t.field != "disk" {
+			return term{}, fmt.Errorf("%s cannot be compared with %s", t.field, op)
+		}
+
+		err := t.parseThreshold()
+		if err != nil {
+			return term{}, err
+		}
+		return t, nil
+	}
+
+	parts := strings.SplitN(word, ":", 2)
+	if len(parts) == 1 {
+		t.field, t.op, t.value = "guid", ":", parts[0]
+	} else {
+		t.field, t.op, t.value = strings.ToLower(parts[0]), ":", parts[1]
+	}
+
+	if !fields[t.field] {
+		return term{}, fmt.Errorf("unknown filter field: %s", t.field)
+	}
+	if t.field == "cpu" || t.field == "memory" || t.field == "disk" {
+		return term{}, fmt.Errorf("%s needs a comparison, e.g. %s>80", t.field, t.field)
+	}
+
+	return t, nil
+}
+
+func (t *term) parseThreshold() error {
+	value := t.value
+	if strings.HasSuffix(value, "%") {
+		t.relative = true
+		value = strings.TrimSuffix(value, "%")
+	}
+
+	if t.field == "cpu" || t.relative {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s threshold: %s", t.field, t.value)
+		}
+		t.threshold = threshold
+		return nil
+	}
+
+	threshold, err := humanize.ParseBytes(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s threshold: %s", t.field, t.value)
+	}
+	t.threshold = float64(threshold)
+	return nil
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expression
+}
+
+func (f *Filter) IsEmpty() bool {
+	return f == nil || len(f.terms) == 0
+}
+
+// Apply returns the LRPs that match, each holding only the matching
+// instances. LRPs without instances are kept if no instance level term is
+// used.
+func (f *Filter) Apply(lrps fetcher.LRPs) fetcher.LRPs {
+	if f.IsEmpty() {
+		return lrps
+	}
+
+	filtered := fetcher.LRPs{}
+	for processGuid, lrp := range lrps {
+		if !f.MatchLRP(lrp) {
+			continue
+		}
+
+		matching := &fetcher.LRP{Desired: lrp.Desired}
+		for _, actual := range lrp.Actuals {
+			if f.MatchActual(lrp, actual) {
+				matching.Actuals = append(matching.Actuals, actual)
+			}
+		}
+
+		if len(matching.Actuals) == 0 && f.hasInstanceTerms() {
+			continue
+		}
+		filtered[processGuid] = matching
+	}
+
+	return filtered
+}
+
+func (f *Filter) ApplyTasks(tasks fetcher.Tasks) fetcher.Tasks {
+	if f.IsEmpty() {
+		return tasks
+	}
+
+	filtered := fetcher.Tasks{}
+	for _, task := range tasks {
+		if f.MatchTask(task) {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
+
+func (f *Filter) ApplyOrphans(orphans fetcher.Orphans) fetcher.Orphans {
+	if f.IsEmpty() {
+		return orphans
+	}
+
+	filtered := fetcher.Orphans{}
+	for _, actual := range orphans {
+		if f.MatchOrphan(actual) {
+			filtered = append(filtered, actual)
+		}
+	}
+	return filtered
+}
+
+func (f *Filter) ApplyCells(cells []*fetcher.CellState) []*fetcher.CellState {
+	if f.IsEmpty() {
+		return cells
+	}
+
+	filtered := []*fetcher.CellState{}
+	for _, cell := range cells {
+		if f.MatchCell(cell) {
+			filtered = append(filtered, cell)
+		}
+	}
+	return filtered
+}
+
+func (f *Filter) MatchLRP(lrp *fetcher.LRP) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, t := range f.terms {
+		var matches bool
+		switch t.field {
+		case "guid":
+			matches = strings.HasPrefix(lrp.Desired.ProcessGuid, t.value)
+		case "domain":
+			matches = strings.HasPrefix(lrp.Desired.Domain, t.value)
+		case "route":
+			matches = matchRoutes(lrp.Desired.Routes, t.value)
+		case "app", "space", "org":
+			matches = f.matchNames(lrp.Desired.ProcessGuid, t)
+		default:
+			continue
+		}
+
+		if matches == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) MatchActual(lrp *fetcher.LRP, actual *fetcher.Actual) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, t := range f.terms {
+		var matches bool
+		switch t.field {
+		case "state":
+			matches = containsFold(actual.ActualLRP.State, t.value)
+		case "cell":
+			matches = strings.Contains(actual.ActualLRP.CellId, t.value)
+		case "cpu":
+			matches = t.compare(actual.Metrics.CPU*100, 100)
+		case "memory":
+			matches = t.compare(float64(actual.Metrics.Memory), float64(fetcher.Megabytes(lrp.Desired.MemoryMb)))
+		case "disk":
+			matches = t.compare(float64(actual.Metrics.Disk), float64(fetcher.Megabytes(lrp.Desired.DiskMb)))
+		default:
+			continue
+		}
+
+		if matches == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOrphan is like MatchActual for actuals without a desired LRP, so
+// routes and percentages of limits never match.
+func (f *Filter) MatchOrphan(actual *fetcher.Actual) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, t := range f.terms {
+		var matches bool
+		switch t.field {
+		case "guid":
+			matches = strings.HasPrefix(actual.ActualLRP.ProcessGuid, t.value)
+		case "domain":
+			matches = strings.HasPrefix(actual.ActualLRP.Domain, t.value)
+		case "state":
+			matches = containsFold(actual.ActualLRP.State, t.value)
+		case "cell":
+			matches = strings.Contains(actual.ActualLRP.CellId, t.value)
+		case "app", "space", "org":
+			matches = f.matchNames(actual.ActualLRP.ProcessGuid, t)
+		case "cpu":
+			matches = t.compare(actual.Metrics.CPU*100, 100)
+		case "memory":
+			matches = t.compare(float64(actual.Metrics.Memory), 0)
+		case "disk":
+			matches = t.compare(float64(actual.Metrics.Disk), 0)
+		}
+
+		if matches == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTask only considers terms that make sense for tasks; routes,
+// metrics and Cloud Controller names never match a task.
+func (f *Filter) MatchTask(task *models.Task) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, t := range f.terms {
+		var matches bool
+		switch t.field {
+		case "guid":
+			matches = strings.HasPrefix(task.TaskGuid, t.value)
+		case "domain":
+			matches = strings.HasPrefix(task.Domain, t.value)
+		case "state":
+			matches = containsFold(task.State.String(), t.value)
+		case "cell":
+			matches = strings.Contains(task.CellId, t.value)
+		}
+
+		if matches == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchCell only considers cell terms.
+func (f *Filter) MatchCell(cell *fetcher.CellState) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, t := range f.terms {
+		if t.field != "cell" {
+			continue
+		}
+		if strings.Contains(cell.CellId, t.value) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) hasInstanceTerms() bool {
+	for _, t := range f.terms {
+		switch t.field {
+		case "state", "cell", "cpu", "memory", "disk":
+			return true
+		}
+	}
+	return false
+}
+
+// compare checks value against the threshold, as a percentage of limit if
+// the threshold was given in percent.
+func (t term) compare(value, limit float64) bool {
+	if t.relative {
+		if limit == 0 {
+			return false
+		}
+		value = 100 * value / limit
+	}
+
+	switch t.op {
+	case ">":
+		return value > t.threshold
+	case ">=":
+		return value >= t.threshold
+	case "<":
+		return value < t.threshold
+	case "<=":
+		return value <= t.threshold
+	}
+	return false
+}
+
+func (f *Filter) matchNames(processGuid string, t term) bool {
+	if f.names == nil {
+		return false
+	}
+
+	app, ok := f.names.Lookup(processGuid)
+	if !ok {
+		return false
+	}
+
+	switch t.field {
+	case "app":
+		return containsFold(app.Name, t.value)
+	case "space":
+		return containsFold(app.Space, t.value)
+	default:
+		return containsFold(app.Org, t.value)
+	}
+}
+
+type cfRoute struct {
+	Hostnames []string `json:"hostnames"`
+}
+
+func matchRoutes(routes *models.Routes, host string) bool {
+	if routes == nil {
+		return false
+	}
+
+	raw, ok := (*routes)["cf-router"]
+	if !ok || raw == nil {
+		return false
+	}
+
+	cfRoutes := []cfRoute{}
+	err := json.Unmarshal(*raw, &cfRoutes)
+	if err != nil {
+		return false
+	}
+
+	for _, route := range cfRoutes {
+		for _, hostname := range route.Hostnames {
+			if strings.Contains(hostname, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
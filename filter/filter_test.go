@@ -0,0 +1,210 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/luan/dope/cloud_controller"
+	"github.com/luan/dope/fetcher"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"",
+		"abc",
+		"guid:abc domain:cf",
+		"!state:crash cell:z1",
+		"route:foo app:web space:dev org:acme",
+		"cpu>80 memory>=1GB disk<90%",
+		"Domain:cf",
+	}
+	for _, expression := range valid {
+		_, err := Parse(expression)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", expression, err)
+		}
+	}
+
+	invalid := []string{
+		"color:red",
+		"domain>cf",
+		"cpu:80",
+		"memory>lots",
+		"cpu>x%",
+	}
+	for _, expression := range invalid {
+		_, err := Parse(expression)
+		if err == nil {
+			t.Errorf("Parse(%q) did not fail", expression)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	var f *Filter
+	if !f.IsEmpty() || f.String() != "" {
+		t.Error("nil filter is not empty")
+	}
+
+	f = mustParse(t, "  ")
+	if !f.IsEmpty() {
+		t.Error("blank filter is not empty")
+	}
+
+	f = mustParse(t, " domain:cf ")
+	if f.IsEmpty() || f.String() != "domain:cf" {
+		t.Errorf("unexpected filter %q", f.String())
+	}
+}
+
+func TestApply(t *testing.T) {
+	lrps := fetcher.LRPs{
+		"web-guid":    newLRP("web-guid", "cf-apps", 1024, newActual("web-guid", "cell_z1-0", "RUNNING", 0.5, 600*1024*1024)),
+		"worker-guid": newLRP("worker-guid", "cf-apps", 1024, newActual("worker-guid", "cell_z2-0", "CRASHED", 0.1, 1000)),
+		"idle-guid":   newLRP("idle-guid", "other", 0),
+	}
+
+	cases := []struct {
+		expression string
+		lrps       []string
+	}{
+		{"", []string{"idle-guid", "web-guid", "worker-guid"}},
+		{"web", []string{"web-guid"}},
+		{"domain:cf", []string{"web-guid", "worker-guid"}},
+		{"!domain:cf", []string{"idle-guid"}},
+		{"state:crash", []string{"worker-guid"}},
+		{"state:CRASH", []string{"worker-guid"}},
+		{"cell:z1", []string{"web-guid"}},
+		{"cpu>40", []string{"web-guid"}},
+		{"cpu<=10", []string{"worker-guid"}},
+		{"memory>=50%", []string{"web-guid"}},
+		{"memory<100KB", []string{"worker-guid"}},
+	}
+	for _, c := range cases {
+		filtered := mustParse(t, c.expression).Apply(lrps)
+		guids := []string{}
+		for _, lrp := range filtered.SortedByProcessGuid() {
+			guids = append(guids, lrp.Desired.ProcessGuid)
+		}
+		if !equal(guids, c.lrps) {
+			t.Errorf("%q matched %v, expected %v", c.expression, guids, c.lrps)
+		}
+	}
+}
+
+func TestApplyKeepsMatchingInstances(t *testing.T) {
+	lrp := newLRP("web-guid", "cf-apps", 1024,
+		newActual("web-guid", "cell_z1-0", "RUNNING", 0, 0),
+		newActual("web-guid", "cell_z2-0", "RUNNING", 0, 0),
+	)
+
+	filtered := mustParse(t, "cell:z2").Apply(fetcher.LRPs{"web-guid": lrp})
+	actuals := filtered["web-guid"].Actuals
+	if len(actuals) != 1 || actuals[0].ActualLRP.CellId != "cell_z2-0" {
+		t.Errorf("unexpected instances %v", actuals)
+	}
+	if len(lrp.Actuals) != 2 {
+		t.Error("Apply changed the LRP it was given")
+	}
+}
+
+func TestMatchTask(t *testing.T) {
+	task := &models.Task{TaskGuid: "task-guid", Domain: "cf-tasks", CellId: "cell_z1-0", State: models.Task_Running}
+
+	cases := map[string]bool{
+		"task":       true,
+		"domain:cf":  true,
+		"cell:z2":    false,
+		"!cell:z2":   true,
+		"route:foo":  false,
+		"app:web":    false,
+		"state:run":  true,
+		"!state:run": false,
+	}
+	for expression, expected := range cases {
+		if mustParse(t, expression).MatchTask(task) != expected {
+			t.Errorf("%q matching task: expected %t", expression, expected)
+		}
+	}
+}
+
+func TestMatchCell(t *testing.T) {
+	cell := &fetcher.CellState{CellId: "cell_z1-0"}
+
+	cases := map[string]bool{
+		"cell:z1":   true,
+		"cell:z2":   false,
+		"!cell:z2":  true,
+		"domain:cf": true,
+	}
+	for expression, expected := range cases {
+		if mustParse(t, expression).MatchCell(cell) != expected {
+			t.Errorf("%q matching cell: expected %t", expression, expected)
+		}
+	}
+}
+
+func TestMatchNames(t *testing.T) {
+	lrp := newLRP("web-guid", "cf-apps", 1024)
+
+	f := mustParse(t, "app:WEB space:dev")
+	if f.MatchLRP(lrp) {
+		t.Error("matched names without a way to look them up")
+	}
+
+	lookup := names{"web-guid": {Name: "web", Space: "dev", Org: "acme"}}
+	f.SetNames(lookup)
+	if !f.MatchLRP(lrp) {
+		t.Error("did not match the looked up names")
+	}
+
+	f = mustParse(t, "org:other")
+	f.SetNames(lookup)
+	if f.MatchLRP(lrp) {
+		t.Error("matched another org")
+	}
+}
+
+type names map[string]cloud_controller.App
+
+func (n names) Lookup(processGuid string) (cloud_controller.App, bool) {
+	app, ok := n[processGuid]
+	return app, ok
+}
+
+func mustParse(t *testing.T, expression string) *Filter {
+	f, err := Parse(expression)
+	if err != nil {
+		t.Fatalf("Parse(%q): %s", expression, err)
+	}
+	return f
+}
+
+func newLRP(processGuid, domain string, memoryMb int32, actuals ...*fetcher.Actual) *fetcher.LRP {
+	return &fetcher.LRP{
+		Desired: &models.DesiredLRP{ProcessGuid: processGuid, Domain: domain, MemoryMb: memoryMb},
+		Actuals: actuals,
+	}
+}
+
+func newActual(processGuid, cellId, state string, cpu float64, memory uint64) *fetcher.Actual {
+	actualLRP := &models.ActualLRP{State: state}
+	actualLRP.ProcessGuid = processGuid
+	actualLRP.CellId = cellId
+	return &fetcher.Actual{
+		ActualLRP: actualLRP,
+		Metrics:   fetcher.ContainerMetrics{CPU: cpu, Memory: memory},
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,41 +1,217 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
 	"runtime"
 	"time"
 
+	"github.com/cloudfoundry/noaa"
+	"github.com/luan/dope/actions"
+	"github.com/luan/dope/api"
 	"github.com/luan/dope/config_finder"
+	"github.com/luan/dope/exporter"
 	"github.com/luan/dope/fetcher"
+	"github.com/luan/dope/filter"
 )
 
+var (
+	batchMode  = flag.Bool("b", false, "batch mode: print plain text snapshots to stdout instead of starting the UI")
+	iterations = flag.Int("n", 1, "number of snapshots to print in batch mode, 0 to print until killed")
+	delay      = flag.Duration("d", time.Second, "delay between snapshots in batch and prometheus modes")
+
+	httpAddress       = flag.String("http", "", "serve a JSON API and web dashboard on this address, e.g. :8080, instead of starting the UI")
+	prometheusAddress = flag.String("prometheus", "", "serve /metrics in the prometheus format on this address, e.g. :9100, instead of starting the UI")
+
+	exportFormat = flag.String("export", "", "write a single snapshot as json or csv and exit")
+	exportOutput = flag.String("o", "", "export destination: a file for json (default stdout), a directory for csv (default .)")
+
+	filterExpression = flag.String("filter", "", "only show LRPs, instances, tasks and cells matching this filter, e.g. 'state:crashed cpu>80'")
+
+	recordFile = flag.String("record", "", "append every snapshot shown in the UI to this file")
+	replayFile = flag.String("replay", "", "play back a recording instead of connecting to the BBS")
+
+	configPath  = flag.String("config", config_finder.DefaultConfigPath(), "config file with named profiles of endpoints, credentials and preferences")
+	profileName = flag.String("env", "", "profile of the config file to use, its default profile if empty")
+
+	bbsURL               = flag.String("bbs-url", "", "BBS endpoint, overrides BBS_ENDPOINT and the profile")
+	bbsCertFile          = flag.String("bbs-cert-file", "", "BBS client certificate, overrides BBS_CERT_FILE and the profile")
+	bbsKeyFile           = flag.String("bbs-key-file", "", "BBS client key, overrides BBS_KEY_FILE and the profile")
+	bbsCAFile            = flag.String("bbs-ca-file", "", "CA certificate to verify the BBS with, overrides BBS_CA_FILE and the profile")
+	bbsServerName        = flag.String("bbs-server-name", "", "name expected on the BBS certificate, if not the host of its URL, overrides BBS_SERVER_NAME and the profile")
+	trafficControllerURL = flag.String("traffic-controller-url", "", "traffic controller endpoint, overrides TRAFFIC_CONTROLLER_URL and the profile")
+	trafficControllerCA  = flag.String("traffic-controller-ca-file", "", "CA certificate to verify the traffic controller with, overrides TRAFFIC_CONTROLLER_CA_FILE and the profile")
+	uaaCAFile            = flag.String("uaa-ca-file", "", "CA certificate to verify the UAA with, overrides UAA_CA_FILE and the profile")
+	cloudControllerURL   = flag.String("cloud-controller-url", "", "Cloud Controller API to look up app, space and org names from, overrides CLOUD_CONTROLLER_URL and the profile")
+	cloudControllerCA    = flag.String("cloud-controller-ca-file", "", "CA certificate to verify the Cloud Controller with, overrides CLOUD_CONTROLLER_CA_FILE and the profile")
+	trafficControllerSNI = flag.String("traffic-controller-server-name", "", "name expected on the traffic controller certificate, if not the host of its URL, overrides TRAFFIC_CONTROLLER_SERVER_NAME and the profile")
+	insecureSkipVerify   = flag.Bool("insecure-skip-verify", false, "do not verify the TLS certificates of the BBS, traffic controller, UAA and Cloud Controller")
+
+	readOnly = flag.Bool("read-only", false, "disable every UI action that changes the BBS, such as retiring instances, resolving tasks or scaling LRPs")
+)
+
+// namesInterval is how often app, space and org names are refetched from
+// the Cloud Controller.
+const namesInterval = time.Minute
+
 func main() {
+	flag.Parse()
 	runtime.GOMAXPROCS(runtime.NumCPU())
-	bbsClient, err := config_finder.NewBBS()
+
+	// a replay needs no endpoints, so it only warns about the config file
+	config, configErr := loadConfig()
+	if configErr != nil && *replayFile == "" {
+		fmt.Fprintln(os.Stderr, configErr)
+		os.Exit(1)
+	}
+
+	lrpFilter, err := filter.Parse(config.Filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *replayFile != "" {
+		err := runReplay(*replayFile, lrpFilter, configErr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	bbsProxyErrors := make(chan error, 1)
+	config.BBS.ProxyErrors = bbsProxyErrors
+	bbsClient, err := config_finder.NewBBS(config.BBS)
 	if err != nil {
 		panic(err)
 	}
+	headless := *exportFormat != "" || *httpAddress != "" || *prometheusAddress != "" || *batchMode
+	if headless {
+		go func() {
+			for err := range bbsProxyErrors {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
 
-	noaaClient, err := config_finder.NewNoaaConsumer()
+	noaaClient, err := config_finder.NewNoaaConsumer(config.Noaa)
 	if err != nil {
 		panic(err)
 	}
 
+	warning := config.InsecureWarning()
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, "WARNING:", warning)
+	}
+
+	tokenSource, err := config_finder.NewTokenSource(config)
+	if err != nil {
+		panic(err)
+	}
+	token := fetcher.TokenFunc(tokenSource)
+	names, err := config_finder.NewCloudController(config.CloudController, token)
+	if err != nil {
+		panic(err)
+	}
+	lrpFilter.SetNames(names)
+
+	if *exportFormat != "" {
+		err := runExport(fetcher.NewFetcher(bbsClient, noaaClient, token), *exportFormat, *exportOutput)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *httpAddress != "" {
+		server := api.NewServer()
+		go func() {
+			streamer := fetcher.NewStreamer(bbsClient, noaaClient, token)
+			streamer.Stream(server.SetState, func(err error) {
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			})
+		}()
+
+		err := http.ListenAndServe(*httpAddress, server)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *prometheusAddress != "" {
+		exporter := exporter.New()
+		go exporter.Run(fetcher.NewFetcher(bbsClient, noaaClient, token), *delay)
+
+		http.Handle("/metrics", exporter)
+		err := http.ListenAndServe(*prometheusAddress, nil)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *batchMode {
+		if names != nil {
+			err := names.Refresh()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "looking up app names:", err)
+			}
+		}
+
+		err := runBatch(fetcher.NewFetcher(bbsClient, noaaClient, token), lrpFilter, *iterations, *delay, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var recorder *fetcher.Recorder
+	if *recordFile != "" {
+		recorder, err = fetcher.NewRecorder(*recordFile)
+		if err != nil {
+			panic(err)
+		}
+		defer recorder.Close()
+	}
+
 	ui := NewUI()
+	ui.SetFilter(lrpFilter)
+	ui.SetWarning(warning)
+	ui.EnableLogs(fetcher.NewLogTailer(func() (*noaa.Consumer, error) {
+		return config_finder.NewNoaaConsumer(config.Noaa)
+	}, token))
+	ui.EnableActions(actions.NewRunner(bbsClient, config.ReadOnly))
+	ui.EnableNames(names)
 	ui.Setup()
 	defer ui.Close()
 
+	if names != nil {
+		go names.Run(namesInterval, ui.SetNamesError)
+	}
+
 	go func() {
-		defer ui.Close()
-		fetcher := fetcher.NewFetcher(bbsClient, noaaClient)
-		for {
-			state, err := fetcher.Fetch()
-			if err != nil {
-				panic(err)
-			}
-			ui.SetState(&state)
-			time.Sleep(time.Second)
+		for err := range bbsProxyErrors {
+			ui.notify(err.Error())
 		}
 	}()
 
+	go func() {
+		streamer := fetcher.NewStreamer(bbsClient, noaaClient, token)
+		streamer.Stream(func(state *fetcher.Data) {
+			if recorder != nil {
+				err := recorder.Record(state.Timestamp, state)
+				if err != nil {
+					recorder = nil
+					ui.SetStatus(fmt.Sprintf("recording stopped: %s", err))
+				}
+			}
+			ui.SetState(state)
+		}, ui.SetError)
+	}()
+
 	ui.Loop()
 }
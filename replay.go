@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui"
+	"github.com/luan/dope/fetcher"
+	"github.com/luan/dope/filter"
+)
+
+// runReplay plays back a recording; configErr is only shown as a warning
+// since a replay does not connect to anything.
+func runReplay(path string, lrpFilter *filter.Filter, configErr error) error {
+	replayer, err := fetcher.NewReplayer(path)
+	if err != nil {
+		return err
+	}
+
+	ui := NewUI()
+	ui.SetFilter(lrpFilter)
+	warnings := []string{}
+	if configErr != nil {
+		warnings = append(warnings, fmt.Sprintf("ignoring the config file: %s", configErr))
+	}
+	if replayer.Damaged() != nil {
+		warnings = append(warnings, replayer.Damaged().Error())
+	}
+	ui.SetWarning(strings.Join(warnings, "; "))
+	ui.Setup()
+	defer ui.Close()
+	bindReplayControls(ui, replayer)
+
+	go func() {
+		for {
+			state, err := replayer.Fetch()
+			if err != nil {
+				ui.Close()
+				panic(err)
+			}
+			ui.SetStatus(replayer.Status())
+			ui.SetState(&state)
+		}
+	}()
+
+	ui.Loop()
+	return nil
+}
+
+func bindReplayControls(ui *UI, replayer *fetcher.Replayer) {
+	control := func(action func()) func(termui.Event) {
+		return func(termui.Event) {
+			action()
+			ui.SetStatus(replayer.Status())
+			ui.Render()
+		}
+	}
+
+	ui.Bind("<space>", control(replayer.TogglePause))
+	ui.Bind(".", control(func() { replayer.Step(1) }))
+	ui.Bind(",", control(func() { replayer.Step(-1) }))
+	ui.Bind("]", control(func() { replayer.Seek(time.Minute) }))
+	ui.Bind("[", control(func() { replayer.Seek(-time.Minute) }))
+	ui.Bind("+", control(func() { replayer.SetSpeed(2) }))
+	ui.Bind("-", control(func() { replayer.SetSpeed(0.5) }))
+}
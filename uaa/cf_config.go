@@ -0,0 +1,38 @@
+package uaa
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// CFConfig is the part of the cf CLI's config.json that holds its tokens.
+type CFConfig struct {
+	AccessToken  string
+	RefreshToken string
+	UaaEndpoint  string
+}
+
+// CFConfigPath is where the cf CLI keeps its config, honoring CF_HOME.
+func CFConfigPath() string {
+	home := os.Getenv("CF_HOME")
+	if home == "" {
+		home = os.Getenv("HOME")
+	}
+	return filepath.Join(home, ".cf", "config.json")
+}
+
+func LoadCFConfig(path string) (*CFConfig, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &CFConfig{}
+	err = json.Unmarshal(contents, config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
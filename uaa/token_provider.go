@@ -0,0 +1,111 @@
+package uaa
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshMargin is how long before it expires a token is replaced.
+const refreshMargin = time.Minute
+
+// TokenProvider gets tokens from UAA with either client credentials or a
+// refresh token and caches them until shortly before they expire.
+type TokenProvider struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	lock         sync.Mutex
+	refreshToken string
+	accessToken  string
+	expiresAt    time.Time
+}
+
+// NewClientCredentialsProvider authenticates as a UAA client, which needs
+// the doppler.firehose or cloud_controller.admin authority to read the
+// metrics of every app.
+func NewClientCredentialsProvider(uaaURL, clientID, clientSecret string, tlsConfig *tls.Config) *TokenProvider {
+	return newTokenProvider(uaaURL, clientID, clientSecret, "", tlsConfig)
+}
+
+// NewRefreshTokenProvider authenticates as a user, like the cf CLI does
+// with the "cf" client and an empty secret.
+func NewRefreshTokenProvider(uaaURL, clientID, clientSecret, refreshToken string, tlsConfig *tls.Config) *TokenProvider {
+	return newTokenProvider(uaaURL, clientID, clientSecret, refreshToken, tlsConfig)
+}
+
+func newTokenProvider(uaaURL, clientID, clientSecret, refreshToken string, tlsConfig *tls.Config) *TokenProvider {
+	return &TokenProvider{
+		tokenURL:     strings.TrimSuffix(uaaURL, "/") + "/oauth/token",
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		refreshToken: refreshToken,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+// Token returns a token ready for the Authorization header, fetching a new
+// one if the current one is about to expire.
+func (p *TokenProvider) Token() (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.accessToken != "" && time.Now().Add(refreshMargin).Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	form := url.Values{}
+	if p.refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", p.refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	request, err := http.NewRequest("POST", p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.SetBasicAuth(p.clientID, p.clientSecret)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body := struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Error        string `json:"error"`
+		Description  string `json:"error_description"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil && response.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid token response from UAA: %s", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("UAA refused a token (%s): %s %s", response.Status, body.Error, body.Description)
+	}
+
+	p.accessToken = body.TokenType + " " + body.AccessToken
+	p.expiresAt = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	if body.RefreshToken != "" && p.refreshToken != "" {
+		p.refreshToken = body.RefreshToken
+	}
+	return p.accessToken, nil
+}
@@ -5,41 +5,93 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/bbs/models"
 	humanize "github.com/dustin/go-humanize"
 	"github.com/gizak/termui"
+	"github.com/luan/dope/actions"
+	"github.com/luan/dope/cloud_controller"
 	"github.com/luan/dope/fetcher"
+	"github.com/luan/dope/filter"
 )
 
 type content struct {
 	String string
 
-	lrp     *fetcher.LRP
-	desired *models.DesiredLRP
-	actual  *fetcher.Actual
+	lrp      *fetcher.LRP
+	desired  *models.DesiredLRP
+	actual   *fetcher.Actual
+	task     *models.Task
+	cell     *fetcher.CellState
+	zone     *fetcher.ZoneState
+	orphan   *fetcher.Actual
+	crashing *fetcher.CrashingLRP
 }
 
+const (
+	viewLRPs = iota
+	viewTasks
+	viewCells
+	viewCrashing
+	viewZones
+)
+
+var viewLabels = []string{"LRPs", "Tasks", "Cells", "Crashing", "Zones"}
+
 type UI struct {
 	selectedIndex int
 	listContent   []content
 	listOffset    int
 	sort          int
 	sortReverse   bool
+	view          int
+	cellId        string
 	state         *fetcher.Data
+	placement     *fetcher.Placement
+	status        string
+	warning       string
+	err           error
+	filter        *filter.Filter
+	unhealthyOnly bool
+	prompt        *prompt
+	keys          map[string]func(termui.Event)
+	history       *fetcher.History
+	crashes       *fetcher.CrashTracker
+	domains       *fetcher.DomainTracker
+	tailer        *fetcher.LogTailer
+	logs          *logPane
+	runner        *actions.Runner
+	confirmation  *actions.Action
+	names         *cloud_controller.Client
+	namesErr      error
 
 	listWidget    *termui.List
 	detailWidget  *termui.Par
 	summaryWidget *termui.Par
 	cellsWidget   *termui.Par
+	domainsWidget *termui.Par
+	statusWidget  *termui.Par
 }
 
+// historySize is how many one second samples are kept for sparklines.
+const historySize = 60
+
+// crashWindow is how far back crashes count towards the crash rate.
+const crashWindow = 10 * time.Minute
+
 func NewUI() *UI {
-	return &UI{}
+	return &UI{
+		keys:    map[string]func(termui.Event){},
+		history: fetcher.NewHistory(historySize, time.Second),
+		crashes: fetcher.NewCrashTracker(crashWindow),
+		domains: fetcher.NewDomainTracker(),
+	}
 }
 
 func (ui *UI) Render() {
 	var selected content
+	ui.listWidget.BorderLabel = ui.listLabel()
 	ui.listWidget.Items, selected = ui.selectItem()
 	text := ""
 	if selected.desired != nil {
@@ -47,16 +99,20 @@ func (ui *UI) Render() {
 		ui.detailWidget.BorderLabel = "Desired LRP"
 		text = fmt.Sprintf(
 			`[guid:](fg-bold) %s
+%s[zones:](fg-bold) %s
 [start command:](fg-bold)
 %s
 [routes:](fg-bold) %s
 `,
 			selected.desired.ProcessGuid,
+			ui.appDetail(selected.desired.ProcessGuid),
+			fmtDistribution(ui.placement.Distribution(selected.lrp)),
 			selected.lrp.StartCommand(),
 			string(routes),
 		)
 	}
 	if selected.actual != nil {
+		history := ui.history.Instance(selected.actual)
 		ui.detailWidget.BorderLabel = "Actual LRP"
 		ports, _ := json.Marshal(selected.actual.ActualLRP.Ports)
 		text = fmt.Sprintf(
@@ -70,6 +126,10 @@ func (ui *UI) Render() {
 [memory usage:](fg-bold) %s/%s
 [disk usage:](fg-bold) %s/%s
 [crash reason:](fg-bold) %s
+%s
+[cpu history:](fg-bold)    [%s](fg-magenta)
+[memory history:](fg-bold) [%s](fg-cyan)
+[disk history:](fg-bold)   [%s](fg-red)
 `,
 			selected.actual.ActualLRP.ProcessGuid,
 			fmtCell(selected.actual.ActualLRP.CellId),
@@ -78,45 +138,78 @@ func (ui *UI) Render() {
 			selected.actual.ActualLRP.Address,
 			ports,
 			selected.actual.Metrics.CPU*100,
-			fmtBytes(selected.actual.Metrics.Memory), fmtBytes(uint64(selected.lrp.Desired.MemoryMb*1000*1000)),
-			fmtBytes(selected.actual.Metrics.Disk), fmtBytes(uint64(selected.lrp.Desired.DiskMb*1000*1000)),
+			fmtBytes(selected.actual.Metrics.Memory), fmtBytes(fetcher.Megabytes(selected.lrp.Desired.MemoryMb)),
+			fmtBytes(selected.actual.Metrics.Disk), fmtBytes(fetcher.Megabytes(selected.lrp.Desired.DiskMb)),
 			selected.actual.ActualLRP.CrashReason,
+			evacuationDetail(selected.actual, ui.counterpart(selected.actual)),
+			cpuSparkline(history), memorySparkline(history, selected.lrp.Desired.MemoryMb),
+			diskSparkline(history, selected.lrp.Desired.DiskMb),
 		)
 	}
-	dat, _ := ioutil.ReadFile("gopher.txt")
-	gopher := string(dat)
-	ui.detailWidget.Text = text + "\n\n\n\n\n" + gopher
-	var totalCPU float64
-	var totalMemoryUsed uint64
-	var totalMemoryReserved uint64
-	var totalDiskUsed uint64
-	var totalDiskReserved uint64
-	var totalLRPs uint64
-	var totalTasks uint64
+	if selected.task != nil {
+		ui.detailWidget.BorderLabel = "Task"
+		text = taskDetail(selected.task)
+	}
+	if selected.orphan != nil {
+		ui.detailWidget.BorderLabel = "Orphaned Actual LRP"
+		text = orphanDetail(selected.orphan)
+	}
+	if selected.crashing != nil {
+		ui.detailWidget.BorderLabel = "Crashes"
+		text = crashDetail(selected.crashing)
+	}
+	if selected.cell != nil {
+		ui.detailWidget.BorderLabel = "Cell"
+		text = cellDetail(selected.cell, ui.history.Cell(selected.cell.CellId))
+	}
+	if selected.zone != nil {
+		ui.detailWidget.BorderLabel = "Zone"
+		text = zoneDetail(selected.zone, ui.state.GetCellState().SortedByCellId())
+	}
+	if ui.confirmation != nil {
+		ui.detailWidget.BorderLabel = "Confirm"
+		ui.detailWidget.Text = confirmationText(ui.confirmation)
+	} else if ui.logs != nil {
+		ui.detailWidget.BorderLabel = ui.logs.label()
+		ui.detailWidget.Text = ui.logs.render(ui.detailWidget.InnerHeight())
+	} else {
+		dat, _ := ioutil.ReadFile("gopher.txt")
+		gopher := string(dat)
+		ui.detailWidget.Text = text + "\n\n\n\n\n" + gopher
+	}
 	ui.cellsWidget.Text = ""
+	ui.cellsWidget.BorderLabel = "Cells"
+	if ui.warning != "" {
+		ui.cellsWidget.BorderLabel = "Cells - WARNING: " + ui.warning
+		ui.cellsWidget.BorderLabelFg = termui.ColorRed
+	}
+	ui.summaryWidget.Text = ""
+	ui.domainsWidget.Text = ""
+	if ui.state != nil {
+		ui.domainsWidget.Text = ui.domainsText()
+	}
 
-	if ui.state != nil && len(ui.state.LRPs) > 0 {
-		cells := ui.state.GetCellState().SortedByCellId()
+	var cellStates fetcher.CellStates
+	if ui.state != nil {
+		cellStates = ui.state.GetCellState()
+	}
+	if len(cellStates) > 0 {
+		cells := cellStates.SortedByCellId()
 		totalCells := len(cells)
 
 		for _, cell := range cells {
-			totalCPU += cell.CPUPercentage
-			totalMemoryUsed += cell.MemoryUsed
-			totalMemoryReserved += cell.MemoryReserved
-			totalDiskUsed += cell.DiskUsed
-			totalDiskReserved += cell.DiskReserved
-			totalLRPs += cell.NumLRPs
-			totalTasks += cell.NumTasks
-
 			ui.cellsWidget.Text += fmt.Sprintf(
-				`[%8s:](fg-white,fg-bold) | [LRPs:](fg-white,fg-bold) %3d | [Tasks:](fg-white,fg-bold) %3d | [Average CPU:](fg-white,fg-bold) %8.1f%% | [Total Memory:](fg-white,fg-bold) %8s/%-8s | [Total Disk:](fg-white,fg-bold) %8s/%-8s
+				`[%8s:](fg-white,fg-bold) | [LRPs:](fg-white,fg-bold) %3d | [Tasks:](fg-white,fg-bold) %3d | [Average CPU:](fg-white,fg-bold) %8.1f%% | [Total Memory:](fg-white,fg-bold) %8s/%-8s | [Total Disk:](fg-white,fg-bold) %8s/%-8s | [Free:](fg-white,fg-bold) %8s | [%s](fg-cyan)
 `, cell.CellId, cell.NumLRPs, cell.NumTasks,
 				float64(100)*cell.CPUPercentage,
 				fmtBytes(cell.MemoryUsed), fmtBytes(cell.MemoryReserved),
 				fmtBytes(cell.DiskUsed), fmtBytes(cell.DiskReserved),
+				fmtCapacity(cell.MemoryFree(), cell.MemoryCapacity),
+				fmtSparkline(memoryValues(ui.history.Cell(cell.CellId)), float64(cell.MemoryReserved), 20),
 			)
 		}
 
+		total := cellStates.Total()
 		ui.summaryWidget.Text = fmt.Sprintf(
 			`[Cells:](fg-white,fg-bold) %d
 [LRPs:](fg-white,fg-bold) %d
@@ -124,13 +217,20 @@ func (ui *UI) Render() {
 [Average CPU:](fg-white,fg-bold) %.1f%%
 [Total Memory:](fg-white,fg-bold) %s/%s
 [Total Disk:](fg-white,fg-bold) %s/%s
-`, totalCells, totalLRPs, totalTasks,
-			float64(100)*totalCPU/float64(totalCells),
-			fmtBytes(totalMemoryUsed), fmtBytes(totalMemoryReserved),
-			fmtBytes(totalDiskUsed), fmtBytes(totalDiskReserved),
+[Memory Capacity:](fg-white,fg-bold) %s (%s free)
+[Disk Capacity:](fg-white,fg-bold) %s (%s free)
+[Containers:](fg-white,fg-bold) %d/%d (%d free)
+`, totalCells, total.NumLRPs, total.NumTasks,
+			float64(100)*total.CPUPercentage/float64(totalCells),
+			fmtBytes(total.MemoryUsed), fmtBytes(total.MemoryReserved),
+			fmtBytes(total.DiskUsed), fmtBytes(total.DiskReserved),
+			fmtCapacity(total.MemoryCapacity, total.MemoryCapacity), fmtCapacity(total.MemoryFree(), total.MemoryCapacity),
+			fmtCapacity(total.DiskCapacity, total.DiskCapacity), fmtCapacity(total.DiskFree(), total.DiskCapacity),
+			total.NumLRPs+total.NumTasks, total.ContainerCapacity, total.ContainersFree(),
 		)
 
 	}
+	ui.statusWidget.Text = ui.statusText()
 	termui.Render(termui.Body)
 }
 
@@ -151,26 +251,45 @@ func (ui *UI) Setup() {
 	ui.cellsWidget = termui.NewPar("")
 	ui.cellsWidget.BorderLabel = "Cells"
 	ui.cellsWidget.Height = 12
+	ui.domainsWidget = termui.NewPar("")
+	ui.domainsWidget.BorderLabel = "Domains"
+	ui.domainsWidget.Height = 12
+	ui.statusWidget = termui.NewPar("")
+	ui.statusWidget.Height = 3
 
 	termui.Body.AddRows(
 		termui.NewRow(
 			termui.NewCol(3, 0, ui.summaryWidget),
-			termui.NewCol(9, 0, ui.cellsWidget),
+			termui.NewCol(2, 0, ui.domainsWidget),
+			termui.NewCol(7, 0, ui.cellsWidget),
 		),
 		termui.NewRow(
 			termui.NewCol(6, 0, ui.listWidget),
 			termui.NewCol(6, 0, ui.detailWidget),
 		),
+		termui.NewRow(
+			termui.NewCol(12, 0, ui.statusWidget),
+		),
 	)
-	ui.listWidget.Height = termui.TermHeight() - 12
-	ui.detailWidget.Height = termui.TermHeight() - 12
-	termui.Body.Align()
+	ui.layout()
 
 	ui.Render()
 	ui.bindEvents()
 }
 
+// layout gives the list and detail panes whatever height the fixed size
+// widgets leave.
+func (ui *UI) layout() {
+	height := termui.TermHeight() - ui.summaryWidget.Height - ui.statusWidget.Height
+	ui.listWidget.Height = height
+	ui.detailWidget.Height = height
+	termui.Body.Align()
+}
+
 func (ui *UI) Close() {
+	if ui.tailer != nil {
+		ui.tailer.Stop()
+	}
 	termui.Close()
 }
 
@@ -193,17 +312,56 @@ func colorizeState(state string) string {
 	}
 }
 
+// fmtHealth renders a badge with the running and desired instances, red if
+// anything crashed, yellow while instances are starting or missing.
+func fmtHealth(health fetcher.Health) string {
+	badge := fmt.Sprintf(" %d/%d running ", health.Running, health.Desired)
+	if health.Starting() > 0 {
+		badge += fmt.Sprintf("%d starting ", health.Starting())
+	}
+	if health.Crashed > 0 {
+		badge += fmt.Sprintf("%d crashed ", health.Crashed)
+	}
+	if health.MaxCrashCount > 0 {
+		badge += fmt.Sprintf("max crashes %d ", health.MaxCrashCount)
+	}
+
+	switch {
+	case health.Crashed > 0:
+		return fmt.Sprintf("[%s](fg-white,bg-red)", badge)
+	case !health.Healthy():
+		return fmt.Sprintf("[%s](fg-black,bg-yellow)", badge)
+	default:
+		return fmt.Sprintf("[%s](fg-black,bg-green)", badge)
+	}
+}
+
 func fmtBytes(s uint64) string {
 	return strings.Replace(humanize.Bytes(s), " ", "", -1)
 }
 
+// fmtCapacity formats bytes derived from an advertised capacity, which is
+// unknown for cells that have no presence in the BBS.
+func fmtCapacity(s, capacity uint64) string {
+	if capacity == 0 {
+		return "?"
+	}
+	return fmtBytes(s)
+}
+
+// escapeMarkup keeps arbitrary text from being read as termui color markup.
+func escapeMarkup(s string) string {
+	s = strings.Replace(s, "[", "(", -1)
+	s = strings.Replace(s, "]", ")", -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
 func fmtCell(s string) string {
-	parts := strings.Split(s, "_")
-	if len(parts) != 2 {
+	zone, index, ok := fetcher.SplitCellId(s)
+	if !ok {
 		return "none"
 	}
-	parts = strings.Split(parts[1], "-")
-	return fmt.Sprintf("%s/%s", parts[0], parts[1])
+	return fmt.Sprintf("%s/%s", zone, index)
 }
 
 func fmtSort(s string, sort int, reverse bool) string {
@@ -221,8 +379,9 @@ func (ui *UI) lrpToContents(lrp *fetcher.LRP) []content {
 	ret = append(ret,
 		content{
 			String: fmt.Sprintf(
-				"guid: [%s](fg-bold)\t[instances:](fg-white) [%d](fg-white,fg-bold) ",
-				lrp.Desired.ProcessGuid[:8], lrp.Desired.Instances,
+				"guid: [%s](fg-bold)\t[instances:](fg-white) [%d](fg-white,fg-bold) %s %s %s",
+				lrp.Desired.ProcessGuid[:8], lrp.Desired.Instances, fmtHealth(lrp.Health()),
+				ui.fmtSingleZone(lrp), ui.fmtAppName(lrp.Desired.ProcessGuid),
 			),
 			lrp:     lrp,
 			desired: lrp.Desired,
@@ -257,11 +416,12 @@ func (ui *UI) lrpToContents(lrp *fetcher.LRP) []content {
 		ret = append(ret,
 			content{
 				String: fmt.Sprintf(
-					"    [%9d](fg-white) %-8s %s [%6.1f%%](fg-magenta) [%9s](fg-cyan)[/%-8s](fg-cyan,fg-bold) [%9s](fg-red)[/%-8s](fg-red,fg-bold)",
+					"    [%9d](fg-white) %-8s %s [%6.1f%%](fg-magenta) [%9s](fg-cyan)[/%-8s](fg-cyan,fg-bold) [%9s](fg-red)[/%-8s](fg-red,fg-bold) %s",
 					actual.ActualLRP.Index, fmtCell(actual.ActualLRP.CellId), state,
 					actual.Metrics.CPU*100,
-					fmtBytes(actual.Metrics.Memory), fmtBytes(uint64(lrp.Desired.MemoryMb*1000*1000)),
-					fmtBytes(actual.Metrics.Disk), fmtBytes(uint64(lrp.Desired.DiskMb*1000*1000)),
+					fmtBytes(actual.Metrics.Memory), fmtBytes(fetcher.Megabytes(lrp.Desired.MemoryMb)),
+					fmtBytes(actual.Metrics.Disk), fmtBytes(fetcher.Megabytes(lrp.Desired.DiskMb)),
+					fmtEvacuation(actual, lrp.Counterpart(actual)),
 				),
 				lrp:    lrp,
 				actual: actual,
@@ -273,19 +433,188 @@ func (ui *UI) lrpToContents(lrp *fetcher.LRP) []content {
 
 func (ui *UI) refreshState() {
 	ui.listContent = []content{}
-	lrps := ui.state.LRPs.SortedByProcessGuid()
-	for _, lrp := range lrps {
-		content := ui.lrpToContents(lrp)
-		ui.listContent = append(ui.listContent, content...)
+	if ui.state == nil {
+		return
+	}
+
+	switch ui.view {
+	case viewLRPs:
+		lrps := ui.filter.Apply(ui.state.LRPs)
+		if ui.unhealthyOnly {
+			lrps = lrps.Unhealthy()
+		}
+		for _, lrp := range lrps.SortedByProcessGuid() {
+			content := ui.lrpToContents(lrp)
+			ui.listContent = append(ui.listContent, content...)
+		}
+		orphans := ui.filter.ApplyOrphans(ui.state.Orphans).SortedByProcessGuid()
+		ui.listContent = append(ui.listContent, ui.orphansToContents(orphans)...)
+	case viewTasks:
+		ui.listContent = ui.tasksToContents(ui.filter.ApplyTasks(ui.state.Tasks).SortedByTaskGuid())
+	case viewCells:
+		cellStates := ui.state.GetCellState()
+		if cell, ok := cellStates[ui.cellId]; ok {
+			ui.listContent = ui.cellToContents(cell)
+		} else {
+			ui.cellId = ""
+			ui.listContent = ui.cellsToContents(ui.filter.ApplyCells(cellStates.SortedByCellId()))
+		}
+	case viewCrashing:
+		ui.listContent = ui.crashesToContents(ui.crashes.Crashing())
+	case viewZones:
+		ui.listContent = ui.zonesToContents(ui.state.GetCellState().ByZone().SortedByZone())
+	}
+	ui.clampSelection()
+}
+
+func (ui *UI) listLabel() string {
+	label := viewLabels[ui.view]
+	if ui.view == viewCells && ui.cellId != "" {
+		label = "Cell " + ui.cellId
+	}
+	if ui.view == viewLRPs && ui.unhealthyOnly {
+		label += " (unhealthy only)"
+	}
+
+	if ui.prompt != nil {
+		return label + " " + ui.prompt.String()
+	}
+	if !ui.filter.IsEmpty() {
+		return label + " (filter: " + ui.filter.String() + ")"
+	}
+	return label
+}
+
+func (ui *UI) SetFilter(f *filter.Filter) {
+	f.SetNames(ui.names)
+	ui.filter = f
+}
+
+func (ui *UI) openFilterPrompt() {
+	previous := ui.filter
+	setFilter := func(text string) error {
+		f, err := filter.Parse(text)
+		if err != nil {
+			return err
+		}
+		ui.SetFilter(f)
+		return nil
+	}
+
+	ui.openPrompt(&prompt{
+		label:    "/",
+		text:     ui.filter.String(),
+		onChange: setFilter,
+		onSubmit: setFilter,
+		onCancel: func() {
+			ui.filter = previous
+		},
+	})
+}
+
+func (ui *UI) setView(view int) {
+	if ui.view == view {
+		return
+	}
+	ui.view = view
+	ui.selectedIndex = 0
+	ui.listOffset = 0
+	ui.refreshState()
+	ui.Render()
+}
+
+// clampSelection keeps the cursor on an existing row when the list shrinks.
+func (ui *UI) clampSelection() {
+	total := len(ui.listContent)
+	if ui.listOffset+ui.selectedIndex < total {
+		return
+	}
+
+	ui.listOffset = 0
+	ui.selectedIndex = total - 1
+	visibleHeight := ui.listWidget.InnerHeight()
+	if ui.selectedIndex >= visibleHeight {
+		ui.listOffset = total - visibleHeight
+		ui.selectedIndex = visibleHeight - 1
+	}
+	if ui.selectedIndex < 0 {
+		ui.selectedIndex = 0
 	}
 }
 
 func (ui *UI) SetState(state *fetcher.Data) {
+	ui.history.Record(state)
+	ui.crashes.Record(state)
+	ui.domains.Record(state)
 	ui.state = state
+	ui.placement = state.Placement()
 	ui.refreshState()
 	ui.Render()
 }
 
+// SetStatus shows a line of text in the status bar, e.g. where a replay is.
+func (ui *UI) SetStatus(status string) {
+	ui.status = status
+}
+
+// notify shows the outcome of a key press in the status bar right away.
+func (ui *UI) notify(status string) {
+	ui.SetStatus(status)
+	ui.Render()
+}
+
+// SetError shows a fetch error in the status bar, along with how long the
+// data has been stale when the LRPs could not be fetched. The last data set
+// stays on screen. A nil error clears it.
+func (ui *UI) SetError(err error) {
+	ui.err = err
+	ui.Render()
+}
+
+// SetWarning shows a warning above the cells for as long as dope runs,
+// e.g. that TLS certificates are not verified.
+func (ui *UI) SetWarning(warning string) {
+	ui.warning = warning
+}
+
+func (ui *UI) statusText() string {
+	parts := []string{}
+	if ui.warning != "" {
+		parts = append(parts, fmt.Sprintf("[WARNING: %s](fg-white,bg-red)", escapeMarkup(ui.warning)))
+	}
+	if ui.state != nil {
+		if warning := ui.domainsWarning(); warning != "" {
+			parts = append(parts, fmt.Sprintf("[%s](fg-white,bg-red)", warning))
+		}
+	}
+	if ui.err != nil {
+		parts = append(parts, fmt.Sprintf("[error: %s](fg-red,fg-bold)", escapeMarkup(ui.err.Error())))
+	}
+	if ui.namesErr != nil {
+		parts = append(parts, fmt.Sprintf("[looking up app names: %s](fg-red)", escapeMarkup(ui.namesErr.Error())))
+	}
+	if ui.state != nil {
+		// only the LRPs failing leaves the whole view stale
+		fetchErr, partial := ui.err.(*fetcher.FetchError)
+		if ui.err != nil && (!partial || fetchErr.LRPs != nil) {
+			parts = append(parts, fmt.Sprintf(
+				"[stale since %s (%s ago)](fg-yellow)",
+				ui.state.Timestamp.Format("15:04:05"),
+				time.Since(ui.state.Timestamp)/time.Second*time.Second,
+			))
+		} else {
+			parts = append(parts, fmt.Sprintf("[updated %s](fg-green)", ui.state.Timestamp.Format("15:04:05")))
+		}
+	}
+	if ui.runner != nil && ui.runner.ReadOnly() {
+		parts = append(parts, "[read-only](fg-white)")
+	}
+	if ui.status != "" {
+		parts = append(parts, fmt.Sprintf("[%s](fg-cyan,fg-bold)", escapeMarkup(ui.status)))
+	}
+	return strings.Join(parts, " | ")
+}
+
 func (ui *UI) reverseSort() {
 	ui.sortReverse = !ui.sortReverse
 }
@@ -302,57 +631,177 @@ func (ui *UI) setSort(delta int) {
 var sortOptions = []string{"index", "cpu", "memory", "disk"}
 
 func (ui *UI) bindEvents() {
-	termui.Handle("/sys/kbd/q", func(termui.Event) {
+	ui.Bind("q", func(termui.Event) {
 		termui.StopLoop()
 	})
 
-	termui.Handle("/sys/kbd/j", ui.handleDown)
-	termui.Handle("/sys/kbd/<down>", ui.handleDown)
-	termui.Handle("/sys/kbd/k", ui.handleUp)
-	termui.Handle("/sys/kbd/<up>", ui.handleUp)
-	termui.Handle("/sys/kbd/g", ui.handleTop)
-	termui.Handle("/sys/kbd/<home>", ui.handleTop)
-	termui.Handle("/sys/kbd/G", ui.handleBottom)
-	termui.Handle("/sys/kbd/<end>", ui.handleBottom)
+	ui.Bind("1", func(termui.Event) {
+		ui.setView(viewLRPs)
+	})
+
+	ui.Bind("2", func(termui.Event) {
+		ui.setView(viewTasks)
+	})
+
+	ui.Bind("3", func(termui.Event) {
+		ui.setView(viewCells)
+	})
 
-	termui.Handle("/sys/kbd/<right>", func(termui.Event) {
+	ui.Bind("u", func(termui.Event) {
+		ui.unhealthyOnly = !ui.unhealthyOnly
+		ui.refreshState()
+		ui.Render()
+	})
+
+	ui.Bind("4", func(termui.Event) {
+		ui.setView(viewCrashing)
+	})
+
+	ui.Bind("5", func(termui.Event) {
+		ui.setView(viewZones)
+	})
+
+	ui.Bind("L", func(termui.Event) {
+		ui.toggleLogs()
+	})
+
+	ui.Bind("p", func(termui.Event) {
+		if ui.logs != nil {
+			ui.logs.togglePause()
+			ui.Render()
+		}
+	})
+
+	ui.Bind("f", func(termui.Event) {
+		if ui.logs != nil {
+			ui.openLogFilterPrompt()
+		}
+	})
+
+	ui.Bind("<previous>", func(termui.Event) {
+		if ui.logs != nil {
+			ui.logs.scrollBy(ui.detailWidget.InnerHeight())
+			ui.Render()
+		}
+	})
+
+	ui.Bind("<next>", func(termui.Event) {
+		if ui.logs != nil {
+			ui.logs.scrollBy(-ui.detailWidget.InnerHeight())
+			ui.Render()
+		}
+	})
+
+	ui.Bind("K", func(termui.Event) {
+		ui.retireSelected()
+	})
+
+	ui.Bind("C", func(termui.Event) {
+		ui.cancelSelectedTask()
+	})
+
+	ui.Bind("R", func(termui.Event) {
+		ui.resolveSelectedTask()
+	})
+
+	ui.Bind("S", func(termui.Event) {
+		ui.scaleSelectedLRP()
+	})
+
+	ui.Bind("A", func(termui.Event) {
+		ui.annotateSelectedLRP()
+	})
+
+	ui.Bind("H", func(termui.Event) {
+		ui.routeSelectedLRP()
+	})
+
+	ui.Bind("/", func(termui.Event) {
+		ui.openFilterPrompt()
+	})
+
+	ui.Bind("<enter>", func(termui.Event) {
+		ui.enterCell()
+	})
+
+	ui.Bind("<escape>", func(termui.Event) {
+		ui.leaveCell()
+	})
+
+	ui.Bind("<backspace>", func(termui.Event) {
+		ui.leaveCell()
+	})
+
+	ui.Bind("j", ui.handleDown)
+	ui.Bind("<down>", ui.handleDown)
+	ui.Bind("k", ui.handleUp)
+	ui.Bind("<up>", ui.handleUp)
+	ui.Bind("g", ui.handleTop)
+	ui.Bind("<home>", ui.handleTop)
+	ui.Bind("G", ui.handleBottom)
+	ui.Bind("<end>", ui.handleBottom)
+
+	ui.Bind("<right>", func(termui.Event) {
 		ui.setSort(1)
 		ui.refreshState()
 		ui.Render()
 	})
 
-	termui.Handle("/sys/kbd/l", func(termui.Event) {
+	ui.Bind("l", func(termui.Event) {
 		ui.setSort(1)
 		ui.refreshState()
 		ui.Render()
 	})
 
-	termui.Handle("/sys/kbd/<left>", func(termui.Event) {
+	ui.Bind("<left>", func(termui.Event) {
 		ui.setSort(-1)
 		ui.refreshState()
 		ui.Render()
 	})
 
-	termui.Handle("/sys/kbd/h", func(termui.Event) {
+	ui.Bind("h", func(termui.Event) {
 		ui.setSort(-1)
 		ui.refreshState()
 		ui.Render()
 	})
 
-	termui.Handle("/sys/kbd/s", func(termui.Event) {
+	ui.Bind("s", func(termui.Event) {
 		ui.reverseSort()
 		ui.refreshState()
 		ui.Render()
 	})
 
+	termui.Handle("/sys/kbd", ui.handleKey)
+
 	termui.Handle("/sys/wnd/resize", func(termui.Event) {
-		ui.listWidget.Height = termui.TermHeight() - 12
-		ui.detailWidget.Height = termui.TermHeight() - 12
-		termui.Body.Align()
+		ui.layout()
 		ui.Render()
 	})
 }
 
+// Bind sets the handler for a key such as "j" or "<enter>". All keys go
+// through handleKey so that an open prompt can capture them.
+func (ui *UI) Bind(key string, handler func(termui.Event)) {
+	ui.keys[key] = handler
+}
+
+func (ui *UI) handleKey(e termui.Event) {
+	key := e.Data.(termui.EvtKbd).KeyStr
+	if ui.prompt != nil {
+		ui.handlePromptKey(key)
+		return
+	}
+	if ui.confirmation != nil {
+		ui.handleConfirmKey(key)
+		return
+	}
+
+	handler, ok := ui.keys[key]
+	if ok {
+		handler(e)
+	}
+}
+
 func (ui *UI) handleBottom(_ termui.Event) {
 	visibleHeight := ui.listWidget.InnerHeight()
 	totalHeight := len(ui.listContent)
@@ -395,7 +844,7 @@ func (ui *UI) handleUp(_ termui.Event) {
 }
 
 func (ui *UI) selectItem() ([]string, content) {
-	if ui.listWidget.InnerHeight() == 0 {
+	if ui.listWidget.InnerHeight() == 0 || len(ui.listContent) == 0 {
 		return []string{}, content{}
 	}
 	index := ui.selectedIndex
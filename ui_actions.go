@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/luan/dope/actions"
+)
+
+func (ui *UI) EnableActions(runner *actions.Runner) {
+	ui.runner = runner
+}
+
+// confirm shows the calls an action would make until it is confirmed with
+// y or cancelled with n or escape.
+func (ui *UI) confirm(action *actions.Action, err error) {
+	if ui.checkActions() {
+		return
+	}
+	if err != nil {
+		ui.notify(err.Error())
+		return
+	}
+
+	ui.confirmation = action
+	ui.Render()
+}
+
+// checkActions tells why actions cannot be run, if they cannot.
+func (ui *UI) checkActions() bool {
+	switch {
+	case ui.runner == nil:
+		ui.notify("actions are not available")
+	case ui.runner.ReadOnly():
+		ui.notify(actions.ErrReadOnly.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func (ui *UI) handleConfirmKey(key string) {
+	action := ui.confirmation
+
+	switch key {
+	case "y", "Y":
+		ui.confirmation = nil
+		err := ui.runner.Run(action)
+		if err != nil {
+			ui.notify(fmt.Sprintf("%s failed: %s", action.Description, err))
+			return
+		}
+		ui.notify(action.Description + ": done")
+	case "n", "N", "<escape>", "q":
+		ui.confirmation = nil
+		ui.Render()
+	}
+}
+
+func confirmationText(action *actions.Action) string {
+	text := fmt.Sprintf("[%s?](fg-yellow,fg-bold)\n\nThis calls the BBS client with:\n\n", action.Description)
+	for _, call := range action.Calls {
+		text += fmt.Sprintf("  [%s](fg-white,fg-bold)\n", escapeMarkup(call))
+	}
+	if len(action.Diff) > 0 {
+		text += "\n"
+	}
+	for _, line := range action.Diff {
+		color := "fg-green"
+		if strings.HasPrefix(line, "-") {
+			color = "fg-red"
+		}
+		text += fmt.Sprintf("  [%s](%s)\n", escapeMarkup(line), color)
+	}
+	return text + "\n[y](fg-green,fg-bold) to confirm, [n](fg-red,fg-bold) or [esc](fg-red,fg-bold) to cancel"
+}
+
+func (ui *UI) retireSelected() {
+	_, selected := ui.selectItem()
+	actual := selected.actual
+	if actual == nil {
+		actual = selected.orphan
+	}
+	if actual == nil {
+		ui.notify("select an instance to retire")
+		return
+	}
+
+	ui.confirm(actions.RetireActualLRP(actual.ActualLRP, actual.Evacuating))
+}
+
+func (ui *UI) cancelSelectedTask() {
+	_, selected := ui.selectItem()
+	if selected.task == nil {
+		ui.notify("select a task to cancel")
+		return
+	}
+
+	ui.confirm(actions.CancelTask(selected.task))
+}
+
+func (ui *UI) resolveSelectedTask() {
+	_, selected := ui.selectItem()
+	if selected.task == nil {
+		ui.notify("select a task to resolve")
+		return
+	}
+
+	ui.confirm(actions.ResolveTask(selected.task))
+}
+
+// selectedDesired returns the desired LRP of the selected row, telling the
+// user to select one otherwise.
+func (ui *UI) selectedDesired(verb string) *models.DesiredLRP {
+	_, selected := ui.selectItem()
+	if selected.lrp == nil {
+		ui.notify("select an LRP to " + verb)
+		return nil
+	}
+	return selected.lrp.Desired
+}
+
+// editLRP opens a prompt for one field of a desired LRP and previews the
+// resulting update before applying it.
+func (ui *UI) editLRP(desired *models.DesiredLRP, label, text string, update func(string) (*models.DesiredLRPUpdate, error)) {
+	if ui.checkActions() {
+		return
+	}
+
+	ui.openPrompt(&prompt{
+		label: label,
+		text:  text,
+		onSubmit: func(text string) error {
+			desiredLRPUpdate, err := update(text)
+			if err != nil {
+				return err
+			}
+
+			action, err := actions.UpdateDesiredLRP(desired, desiredLRPUpdate)
+			if err != nil {
+				return err
+			}
+			ui.confirm(action, nil)
+			return nil
+		},
+	})
+}
+
+func (ui *UI) scaleSelectedLRP() {
+	desired := ui.selectedDesired("scale")
+	if desired == nil {
+		return
+	}
+
+	ui.editLRP(desired, "instances: ", fmt.Sprint(desired.Instances), func(text string) (*models.DesiredLRPUpdate, error) {
+		instances, err := strconv.ParseInt(strings.TrimSpace(text), 10, 32)
+		if err != nil || instances < 0 {
+			return nil, fmt.Errorf("%q is not an instance count", text)
+		}
+		count := int32(instances)
+		return &models.DesiredLRPUpdate{Instances: &count}, nil
+	})
+}
+
+func (ui *UI) annotateSelectedLRP() {
+	desired := ui.selectedDesired("annotate")
+	if desired == nil {
+		return
+	}
+
+	ui.editLRP(desired, "annotation: ", desired.Annotation, func(text string) (*models.DesiredLRPUpdate, error) {
+		return &models.DesiredLRPUpdate{Annotation: &text}, nil
+	})
+}
+
+func (ui *UI) routeSelectedLRP() {
+	desired := ui.selectedDesired("route")
+	if desired == nil {
+		return
+	}
+
+	hostnames, err := actions.RouterHostnames(desired.Routes)
+	if err != nil {
+		ui.notify(fmt.Sprintf("cannot read routes: %s", err))
+		return
+	}
+
+	ui.editLRP(desired, "hostnames: ", strings.Join(hostnames, ","), func(text string) (*models.DesiredLRPUpdate, error) {
+		hostnames := []string{}
+		for _, hostname := range strings.Split(text, ",") {
+			hostname = strings.TrimSpace(hostname)
+			if hostname != "" {
+				hostnames = append(hostnames, hostname)
+			}
+		}
+
+		routes, err := actions.WithRouterHostnames(desired.Routes, hostnames)
+		if err != nil {
+			return nil, err
+		}
+		return &models.DesiredLRPUpdate{Routes: routes}, nil
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luan/dope/fetcher"
+)
+
+func (ui *UI) cellsToContents(cells []*fetcher.CellState) []content {
+	ret := []content{
+		{
+			String: fmt.Sprintf(
+				"%s %s %s %s %s %s %s %s",
+				"[ cell         ](fg-yellow,bg-reverse)",
+				"[ zone   ](fg-white,bg-reverse)",
+				"[ lrps ](fg-white,bg-reverse)",
+				"[ tasks ](fg-white,bg-reverse)",
+				"[ cpu    ](fg-magenta,bg-reverse)",
+				"[ memory](fg-cyan,bg-reverse)[/reserved  ](fg-cyan,bg-reverse)",
+				"[ disk](fg-red,bg-reverse)[/reserved    ](fg-red,bg-reverse)",
+				"[ free memory/disk  ](fg-green,bg-reverse)",
+			),
+		},
+	}
+
+	for _, cell := range cells {
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					" [%-13s](fg-yellow,fg-bold) %-8s %6d %7d [%7.1f%%](fg-magenta) [%8s](fg-cyan)[/%-8s](fg-cyan,fg-bold) [%8s](fg-red)[/%-8s](fg-red,fg-bold) [%8s/%-8s](fg-green)",
+					truncate(cell.CellId, 13), truncate(escapeMarkup(cell.Zone), 8), cell.NumLRPs, cell.NumTasks,
+					float64(100)*cell.CPUPercentage,
+					fmtBytes(cell.MemoryUsed), fmtBytes(cell.MemoryReserved),
+					fmtBytes(cell.DiskUsed), fmtBytes(cell.DiskReserved),
+					fmtCapacity(cell.MemoryFree(), cell.MemoryCapacity), fmtCapacity(cell.DiskFree(), cell.DiskCapacity),
+				),
+				cell: cell,
+			},
+		)
+	}
+	return ret
+}
+
+// cellToContents lists everything placed on a single cell.
+func (ui *UI) cellToContents(cell *fetcher.CellState) []content {
+	ret := []content{
+		{
+			String: fmt.Sprintf("cell: [%s](fg-yellow,fg-bold) [(esc to go back)](fg-white)", cell.CellId),
+			cell:   cell,
+		},
+		{
+			String: fmt.Sprintf(
+				"    %s %s %s %s %s %s",
+				"[ guid     ](fg-white,bg-reverse)",
+				"[ index ](fg-white,bg-reverse)",
+				"[ state       ](fg-white,bg-reverse)",
+				"[ cpu    ](fg-magenta,bg-reverse)",
+				"[ memory](fg-cyan,bg-reverse)[/total    ](fg-cyan,bg-reverse)",
+				"[ disk](fg-red,bg-reverse)[/total      ](fg-red,bg-reverse)",
+			),
+		},
+	}
+
+	for _, lrp := range ui.filter.Apply(ui.state.LRPsOnCell(cell.CellId)).SortedByProcessGuid() {
+		for _, actual := range lrp.ActualLRPsByIndex(false) {
+			ret = append(ret,
+				content{
+					String: fmt.Sprintf(
+						"    [%-10s](fg-bold) %7d %s [%7.1f%%](fg-magenta) [%8s](fg-cyan)[/%-8s](fg-cyan,fg-bold) [%8s](fg-red)[/%-8s](fg-red,fg-bold) %s %s",
+						shortGuid(lrp.Desired.ProcessGuid), actual.ActualLRP.Index,
+						colorizeState(actual.ActualLRP.State),
+						actual.Metrics.CPU*100,
+						fmtBytes(actual.Metrics.Memory), fmtBytes(fetcher.Megabytes(lrp.Desired.MemoryMb)),
+						fmtBytes(actual.Metrics.Disk), fmtBytes(fetcher.Megabytes(lrp.Desired.DiskMb)),
+						fmtEvacuation(actual, ui.counterpart(actual)),
+						ui.fmtAppName(lrp.Desired.ProcessGuid),
+					),
+					lrp:    lrp,
+					actual: actual,
+				},
+			)
+		}
+	}
+
+	orphans := ui.filter.ApplyOrphans(ui.state.Orphans.OnCell(cell.CellId)).SortedByProcessGuid()
+	ret = append(ret, ui.orphansToContents(orphans)...)
+
+	tasks := ui.filter.ApplyTasks(ui.state.TasksOnCell(cell.CellId)).SortedByTaskGuid()
+	if len(tasks) > 0 {
+		ret = append(ret, content{String: ""})
+		ret = append(ret, ui.tasksToContents(tasks)...)
+	}
+	return ret
+}
+
+func cellDetail(cell *fetcher.CellState, history []fetcher.ContainerMetrics) string {
+	return fmt.Sprintf(
+		`[cell:](fg-bold) %s
+[zone:](fg-bold) %s
+[rootfs providers:](fg-bold) %s
+[LRPs:](fg-bold) %d
+[tasks:](fg-bold) %d
+[containers:](fg-bold) %d/%d
+[cpu:](fg-bold) %.1f%%
+
+[memory used/reserved:](fg-bold) %s/%s
+%s
+[memory reserved/capacity:](fg-bold) %s/%s (%s free)
+%s
+
+[disk used/reserved:](fg-bold) %s/%s
+%s
+[disk reserved/capacity:](fg-bold) %s/%s (%s free)
+%s
+
+[cpu history:](fg-bold)    [%s](fg-magenta)
+[memory history:](fg-bold) [%s](fg-cyan)
+[disk history:](fg-bold)   [%s](fg-red)
+`,
+		cell.CellId,
+		escapeMarkup(cell.Zone),
+		escapeMarkup(strings.Join(cell.RootfsProviders, ", ")),
+		cell.NumLRPs,
+		cell.NumTasks,
+		cell.NumLRPs+cell.NumTasks, cell.ContainerCapacity,
+		float64(100)*cell.CPUPercentage,
+		fmtBytes(cell.MemoryUsed), fmtBytes(cell.MemoryReserved),
+		fmtGauge(cell.MemoryUsed, cell.MemoryReserved, 40),
+		fmtBytes(cell.MemoryReserved), fmtCapacity(cell.MemoryCapacity, cell.MemoryCapacity), fmtCapacity(cell.MemoryFree(), cell.MemoryCapacity),
+		fmtGauge(cell.MemoryReserved, cell.MemoryCapacity, 40),
+		fmtBytes(cell.DiskUsed), fmtBytes(cell.DiskReserved),
+		fmtGauge(cell.DiskUsed, cell.DiskReserved, 40),
+		fmtBytes(cell.DiskReserved), fmtCapacity(cell.DiskCapacity, cell.DiskCapacity), fmtCapacity(cell.DiskFree(), cell.DiskCapacity),
+		fmtGauge(cell.DiskReserved, cell.DiskCapacity, 40),
+		cpuSparkline(history),
+		fmtSparkline(memoryValues(history), float64(cell.MemoryReserved), historySize),
+		fmtSparkline(diskValues(history), float64(cell.DiskReserved), historySize),
+	)
+}
+
+// fmtGauge draws a text bar of the given width showing used out of total.
+func fmtGauge(used, total uint64, width int) string {
+	percent := 0.0
+	if total > 0 {
+		percent = float64(used) / float64(total)
+	}
+
+	filled := int(percent * float64(width))
+	if filled > width {
+		filled = width
+	}
+
+	color := "fg-green"
+	if percent >= 0.9 {
+		color = "fg-red"
+	} else if percent >= 0.7 {
+		color = "fg-yellow"
+	}
+
+	gauge := ""
+	if filled > 0 {
+		gauge += fmt.Sprintf("[%s](%s)", strings.Repeat("█", filled), color)
+	}
+	if filled < width {
+		gauge += fmt.Sprintf("[%s](fg-white)", strings.Repeat("░", width-filled))
+	}
+	return fmt.Sprintf("%s %.0f%%", gauge, percent*100)
+}
+
+func (ui *UI) enterCell() {
+	if ui.view != viewCells || ui.cellId != "" {
+		return
+	}
+
+	_, selected := ui.selectItem()
+	if selected.cell == nil {
+		return
+	}
+
+	ui.cellId = selected.cell.CellId
+	ui.selectedIndex = 0
+	ui.listOffset = 0
+	ui.refreshState()
+	ui.Render()
+}
+
+func (ui *UI) leaveCell() {
+	if ui.view != viewCells || ui.cellId == "" {
+		return
+	}
+
+	ui.cellId = ""
+	ui.selectedIndex = 0
+	ui.listOffset = 0
+	ui.refreshState()
+	ui.Render()
+}
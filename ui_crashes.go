@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luan/dope/fetcher"
+)
+
+// recentCrashes is how many crash reasons are listed under each LRP.
+const recentCrashes = 3
+
+func (ui *UI) crashesToContents(crashing []*fetcher.CrashingLRP) []content {
+	ret := []content{
+		{
+			String: fmt.Sprintf(
+				"%s %s %s %s",
+				"[ guid     ](fg-white,bg-reverse)",
+				"[ crashes/min ](fg-red,bg-reverse)",
+				"[ crashes ](fg-white,bg-reverse)",
+				"[ crash-looping indexes ](fg-white,bg-reverse)",
+			),
+		},
+	}
+
+	lrps := ui.filter.Apply(ui.state.LRPs)
+	for _, lrp := range crashing {
+		if _, ok := lrps[lrp.ProcessGuid]; !ok {
+			continue
+		}
+
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					" [%-8s](fg-bold)   [%11.2f](fg-red) %9d   %s   %s",
+					shortGuid(lrp.ProcessGuid), lrp.Rate, len(lrp.Crashes), fmtLooping(lrp.Looping),
+					ui.fmtAppName(lrp.ProcessGuid),
+				),
+				crashing: lrp,
+			},
+		)
+
+		for i, crash := range lrp.Crashes {
+			if i == recentCrashes {
+				break
+			}
+			ret = append(ret,
+				content{
+					String: fmt.Sprintf(
+						"     [%s](fg-white) index %d on %s: %s",
+						crash.Timestamp.Format("15:04:05"), crash.Index, fmtCell(crash.CellId),
+						truncate(escapeMarkup(crash.Reason), 60),
+					),
+					crashing: lrp,
+				},
+			)
+		}
+	}
+	return ret
+}
+
+func fmtLooping(indexes []int32) string {
+	if len(indexes) == 0 {
+		return "-"
+	}
+
+	looping := []string{}
+	for _, index := range indexes {
+		looping = append(looping, fmt.Sprint(index))
+	}
+	return fmt.Sprintf("[%s](fg-red,fg-bold)", strings.Join(looping, ","))
+}
+
+func crashDetail(lrp *fetcher.CrashingLRP) string {
+	text := fmt.Sprintf(
+		`[guid:](fg-bold) %s
+[crashes per minute:](fg-bold) %.2f
+[crash-looping indexes:](fg-bold) %s
+
+[crashes:](fg-bold)
+`,
+		lrp.ProcessGuid, lrp.Rate, fmtLooping(lrp.Looping),
+	)
+
+	for _, crash := range lrp.Crashes {
+		text += fmt.Sprintf(
+			"%s index %d on %s\n  [%s](fg-red)\n",
+			crash.Timestamp.Format("15:04:05"), crash.Index, crash.CellId,
+			escapeMarkup(crash.Reason),
+		)
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (ui *UI) domainsText() string {
+	lines := []string{}
+	for _, state := range ui.domains.Domains() {
+		switch {
+		case state.Fresh:
+			lines = append(lines, fmt.Sprintf("[%s](fg-green)", escapeMarkup(state.Domain)))
+		case state.Since.IsZero():
+			lines = append(lines, fmt.Sprintf("[%s missing](fg-white,bg-red)", state.Domain))
+		case state.WellKnown:
+			lines = append(lines, fmt.Sprintf("[%s stale %s](fg-white,bg-red)", state.Domain, state.Since.Format("15:04:05")))
+		default:
+			lines = append(lines, fmt.Sprintf("[%s stale %s](fg-yellow)", escapeMarkup(state.Domain), state.Since.Format("15:04:05")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// domainsWarning tells that LRPs of stale well-known domains are not being
+// converged.
+func (ui *UI) domainsWarning() string {
+	stale := []string{}
+	for _, state := range ui.domains.Stale() {
+		if state.Since.IsZero() {
+			stale = append(stale, state.Domain+" is missing")
+		} else {
+			stale = append(stale, fmt.Sprintf("%s is stale since %s", state.Domain, state.Since.Format("15:04:05")))
+		}
+	}
+	if len(stale) == 0 {
+		return ""
+	}
+	return strings.Join(stale, ", ") + ": the BBS will not converge its LRPs"
+}
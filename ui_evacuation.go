@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/luan/dope/fetcher"
+)
+
+// counterpart looks the other half of an evacuation up in the whole state,
+// since the cell view only holds the instances on a single cell.
+func (ui *UI) counterpart(actual *fetcher.Actual) *fetcher.Actual {
+	if lrp, ok := ui.state.LRPs[actual.ActualLRP.ProcessGuid]; ok {
+		return lrp.Counterpart(actual)
+	}
+	return ui.state.Orphans.Counterpart(actual)
+}
+
+// fmtEvacuation marks both halves of an evacuation with the cell of the
+// other one.
+func fmtEvacuation(actual, counterpart *fetcher.Actual) string {
+	switch {
+	case actual.Evacuating && counterpart != nil:
+		return fmt.Sprintf("[evacuating → %s](fg-yellow)", fmtCell(counterpart.ActualLRP.CellId))
+	case actual.Evacuating:
+		return "[evacuating](fg-yellow)"
+	case counterpart != nil:
+		return fmt.Sprintf("[replacing %s](fg-yellow)", fmtCell(counterpart.ActualLRP.CellId))
+	default:
+		return ""
+	}
+}
+
+func evacuationDetail(actual, counterpart *fetcher.Actual) string {
+	switch {
+	case actual.Evacuating && counterpart != nil:
+		return fmt.Sprintf(
+			"[evacuating, replaced by:](fg-yellow,fg-bold) %s on %s (%s)\n",
+			counterpart.ActualLRP.InstanceGuid, counterpart.ActualLRP.CellId,
+			colorizeState(counterpart.ActualLRP.State),
+		)
+	case actual.Evacuating:
+		return "[evacuating, no replacement yet](fg-yellow,fg-bold)\n"
+	case counterpart != nil:
+		return fmt.Sprintf(
+			"[replacing evacuating:](fg-yellow,fg-bold) %s on %s (%s)\n",
+			counterpart.ActualLRP.InstanceGuid, counterpart.ActualLRP.CellId,
+			colorizeState(counterpart.ActualLRP.State),
+		)
+	default:
+		return ""
+	}
+}
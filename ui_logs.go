@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/sonde-go/events"
+	"github.com/luan/dope/fetcher"
+)
+
+// maxLogLines is how many lines are kept while tailing, paused or not.
+const maxLogLines = 1000
+
+// logRenderInterval throttles renders during log bursts.
+const logRenderInterval = 100 * time.Millisecond
+
+type logLine struct {
+	timestamp      time.Time
+	sourceType     string
+	sourceInstance string
+	messageType    events.LogMessage_MessageType
+	message        string
+}
+
+// logPane replaces the detail pane while tailing the logs of an LRP, or of
+// a single instance when index is not -1.
+type logPane struct {
+	processGuid string
+	index       int32
+
+	lock       sync.Mutex
+	lines      []logLine
+	pending    []logLine
+	paused     bool
+	scroll     int
+	filter     string
+	err        error
+	renderedAt time.Time
+}
+
+func (ui *UI) EnableLogs(tailer *fetcher.LogTailer) {
+	ui.tailer = tailer
+}
+
+// toggleLogs tails the logs of the selected LRP or instance, or stops
+// tailing if the log pane is open.
+func (ui *UI) toggleLogs() {
+	if ui.logs != nil {
+		ui.closeLogs()
+		return
+	}
+	if ui.tailer == nil {
+		ui.notify("logs are not available")
+		return
+	}
+
+	_, selected := ui.selectItem()
+	var lrp *fetcher.LRP
+	index := int32(-1)
+	switch {
+	case selected.actual != nil:
+		lrp = selected.lrp
+		index = selected.actual.ActualLRP.Index
+	case selected.lrp != nil:
+		lrp = selected.lrp
+	case selected.crashing != nil:
+		lrp = ui.state.LRPs[selected.crashing.ProcessGuid]
+	}
+	if lrp == nil {
+		ui.notify("select an LRP or instance to tail its logs")
+		return
+	}
+
+	logs := &logPane{processGuid: lrp.Desired.ProcessGuid, index: index}
+	err := ui.tailer.Tail(lrp.Desired.LogGuid, func(message *events.LogMessage) {
+		if logs.add(message) {
+			ui.Render()
+		}
+	}, func(err error) {
+		logs.setError(err)
+		ui.Render()
+	})
+	if err != nil {
+		ui.notify(fmt.Sprintf("tailing logs failed: %s", err))
+		return
+	}
+
+	ui.logs = logs
+	ui.Render()
+}
+
+func (ui *UI) closeLogs() {
+	ui.tailer.Stop()
+	ui.logs = nil
+	ui.Render()
+}
+
+func (ui *UI) openLogFilterPrompt() {
+	logs := ui.logs
+	previous := logs.filter
+
+	setFilter := func(text string) error {
+		logs.setFilter(text)
+		return nil
+	}
+
+	ui.openPrompt(&prompt{
+		label:    "log filter: ",
+		text:     previous,
+		onChange: setFilter,
+		onSubmit: setFilter,
+		onCancel: func() {
+			logs.setFilter(previous)
+		},
+	})
+}
+
+// add keeps the message and returns true if the pane should be rendered.
+func (l *logPane) add(message *events.LogMessage) bool {
+	line := logLine{
+		timestamp:      time.Unix(0, message.GetTimestamp()),
+		sourceType:     message.GetSourceType(),
+		sourceInstance: message.GetSourceInstance(),
+		messageType:    message.GetMessageType(),
+		message:        string(message.GetMessage()),
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.paused {
+		l.pending = appendLogLine(l.pending, line)
+		return false
+	}
+	l.lines = appendLogLine(l.lines, line)
+
+	if time.Since(l.renderedAt) < logRenderInterval {
+		return false
+	}
+	l.renderedAt = time.Now()
+	return true
+}
+
+func appendLogLine(lines []logLine, line logLine) []logLine {
+	lines = append(lines, line)
+	if len(lines) > maxLogLines {
+		lines = lines[len(lines)-maxLogLines:]
+	}
+	return lines
+}
+
+func (l *logPane) setError(err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.err = err
+}
+
+func (l *logPane) setFilter(filter string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.filter = filter
+	l.scroll = 0
+}
+
+// togglePause freezes the pane; lines received meanwhile show up on resume.
+func (l *logPane) togglePause() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.paused = !l.paused
+	if !l.paused {
+		for _, line := range l.pending {
+			l.lines = appendLogLine(l.lines, line)
+		}
+		l.pending = nil
+	}
+}
+
+// scrollBy moves the view up by lines, negative values move it back down
+// towards the newest line.
+func (l *logPane) scrollBy(lines int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.scroll += lines
+	if l.scroll < 0 {
+		l.scroll = 0
+	}
+}
+
+func (l *logPane) label() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	label := "Logs " + shortGuid(l.processGuid)
+	if l.index >= 0 {
+		label += fmt.Sprintf(" index %d", l.index)
+	}
+	if l.filter != "" {
+		label += " (filter: " + escapeMarkup(l.filter) + ")"
+	}
+	if l.paused {
+		label += fmt.Sprintf(" [paused, %d new]", len(l.pending))
+	} else if l.scroll > 0 {
+		label += fmt.Sprintf(" [scrolled up %d]", l.scroll)
+	}
+	if l.err != nil {
+		label += " error: " + escapeMarkup(l.err.Error())
+	}
+	return label
+}
+
+// render returns the last height matching lines, shifted by the scroll
+// offset.
+func (l *logPane) render(height int) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	lines := []logLine{}
+	for _, line := range l.lines {
+		if l.matches(line) {
+			lines = append(lines, line)
+		}
+	}
+
+	if l.scroll > len(lines)-height {
+		l.scroll = len(lines) - height
+	}
+	if l.scroll < 0 {
+		l.scroll = 0
+	}
+
+	end := len(lines) - l.scroll
+	start := end - height
+	if start < 0 {
+		start = 0
+	}
+
+	rendered := []string{}
+	for _, line := range lines[start:end] {
+		rendered = append(rendered, fmtLogLine(line))
+	}
+	return strings.Join(rendered, "\n")
+}
+
+func (l *logPane) matches(line logLine) bool {
+	if l.index >= 0 && isInstanceSource(line.sourceType) &&
+		line.sourceInstance != strconv.Itoa(int(l.index)) {
+		return false
+	}
+	return l.filter == "" || strings.Contains(line.message, l.filter)
+}
+
+// isInstanceSource is true for the sources whose instance is the app
+// instance index, rather than the index of a router or stager.
+func isInstanceSource(sourceType string) bool {
+	return strings.HasPrefix(sourceType, "APP") || sourceType == "CELL"
+}
+
+func fmtLogLine(line logLine) string {
+	source := fmt.Sprintf("%s %s/%s", line.timestamp.Format("15:04:05"), line.sourceType, line.sourceInstance)
+	return fmt.Sprintf("[%s](%s) %s", source, logSourceColor(line), escapeMarkup(line.message))
+}
+
+func logSourceColor(line logLine) string {
+	switch {
+	case line.messageType == events.LogMessage_ERR:
+		return "fg-red"
+	case strings.HasPrefix(line.sourceType, "APP"):
+		return "fg-green"
+	case line.sourceType == "RTR":
+		return "fg-cyan"
+	case line.sourceType == "STG":
+		return "fg-yellow"
+	case line.sourceType == "CELL":
+		return "fg-magenta"
+	default:
+		return "fg-blue"
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/luan/dope/cloud_controller"
+)
+
+func (ui *UI) EnableNames(names *cloud_controller.Client) {
+	ui.names = names
+	ui.filter.SetNames(names)
+}
+
+// SetNamesError shows why the app names could not be looked up in the
+// status bar, apart from the fetch error and key press outcomes. A nil
+// error clears it.
+func (ui *UI) SetNamesError(err error) {
+	ui.namesErr = err
+	ui.Render()
+}
+
+// fmtAppName shows org/space/app for CF apps once the Cloud Controller
+// names are known, and nothing otherwise.
+func (ui *UI) fmtAppName(processGuid string) string {
+	app, ok := ui.names.Lookup(processGuid)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("[%s](fg-cyan)", escapeMarkup(app.Org+"/"+app.Space+"/"+app.Name))
+}
+
+func (ui *UI) appDetail(processGuid string) string {
+	app, ok := ui.names.Lookup(processGuid)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(
+		"[app:](fg-bold) %s\n[space:](fg-bold) %s\n[org:](fg-bold) %s\n",
+		escapeMarkup(app.Name), escapeMarkup(app.Space), escapeMarkup(app.Org),
+	)
+}
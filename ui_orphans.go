@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/luan/dope/fetcher"
+)
+
+func (ui *UI) orphansToContents(orphans []*fetcher.Actual) []content {
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	ret := []content{
+		{
+			String: fmt.Sprintf("[ orphaned actual LRPs: %d without a desired LRP ](fg-red,bg-reverse)", len(orphans)),
+		},
+	}
+
+	for _, actual := range orphans {
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					"    [%-10s](fg-red,fg-bold) [%7d](fg-white) %-9s %s [orphan](fg-red) %s %s",
+					shortGuid(actual.ActualLRP.ProcessGuid), actual.ActualLRP.Index,
+					fmtCell(actual.ActualLRP.CellId), colorizeState(actual.ActualLRP.State),
+					fmtEvacuation(actual, ui.counterpart(actual)),
+					ui.fmtAppName(actual.ActualLRP.ProcessGuid),
+				),
+				orphan: actual,
+			},
+		)
+	}
+	return ret
+}
+
+func orphanDetail(actual *fetcher.Actual) string {
+	return fmt.Sprintf(
+		`[no desired LRP exists for this actual LRP](fg-red,fg-bold)
+
+[guid:](fg-bold) %s
+[domain:](fg-bold) %s
+[instance guid:](fg-bold) %s
+[cell:](fg-bold) %s
+[instance index:](fg-bold) %d
+[state:](fg-bold) %s
+[since:](fg-bold) %s
+[address:](fg-bold) %s
+[crash count:](fg-bold) %d
+[crash reason:](fg-bold) %s
+`,
+		actual.ActualLRP.ProcessGuid,
+		actual.ActualLRP.Domain,
+		actual.ActualLRP.InstanceGuid,
+		actual.ActualLRP.CellId,
+		actual.ActualLRP.Index,
+		colorizeState(actual.ActualLRP.State),
+		fmtTimestamp(actual.ActualLRP.Since),
+		actual.ActualLRP.Address,
+		actual.ActualLRP.CrashCount,
+		actual.ActualLRP.CrashReason,
+	)
+}
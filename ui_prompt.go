@@ -0,0 +1,75 @@
+package main
+
+import (
+	"unicode/utf8"
+)
+
+// prompt captures keyboard input until it is submitted with enter or
+// cancelled with escape.
+type prompt struct {
+	label string
+	text  string
+	err   error
+
+	onChange func(text string) error
+	onSubmit func(text string) error
+	onCancel func()
+}
+
+func (ui *UI) openPrompt(p *prompt) {
+	ui.prompt = p
+	ui.Render()
+}
+
+func (ui *UI) handlePromptKey(key string) {
+	p := ui.prompt
+
+	switch key {
+	case "<enter>":
+		if p.onSubmit != nil {
+			p.err = p.onSubmit(p.text)
+			if p.err != nil {
+				ui.Render()
+				return
+			}
+		}
+		ui.prompt = nil
+	case "<escape>":
+		if p.onCancel != nil {
+			p.onCancel()
+		}
+		ui.prompt = nil
+	case "<backspace>", "C-8":
+		if len(p.text) > 0 {
+			_, size := utf8.DecodeLastRuneInString(p.text)
+			p.text = p.text[:len(p.text)-size]
+			ui.changePrompt()
+		}
+	case "<space>":
+		p.text += " "
+		ui.changePrompt()
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		p.text += key
+		ui.changePrompt()
+	}
+
+	ui.refreshState()
+	ui.Render()
+}
+
+func (ui *UI) changePrompt() {
+	if ui.prompt.onChange != nil {
+		ui.prompt.err = ui.prompt.onChange(ui.prompt.text)
+	}
+}
+
+func (p *prompt) String() string {
+	text := p.label + p.text + "█"
+	if p.err != nil {
+		text += " (" + p.err.Error() + ")"
+	}
+	return text
+}
@@ -0,0 +1,74 @@
+package main
+
+import "github.com/luan/dope/fetcher"
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// fmtSparkline draws the last width values scaled to max, or to the largest
+// value if max is not positive.
+func fmtSparkline(values []float64, max float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	if max <= 0 {
+		for _, value := range values {
+			if value > max {
+				max = value
+			}
+		}
+	}
+
+	line := make([]rune, width)
+	for i := range line {
+		line[i] = ' '
+	}
+
+	offset := width - len(values)
+	for i, value := range values {
+		level := 0
+		if max > 0 {
+			level = int(value / max * float64(len(sparks)-1))
+		}
+		if level < 0 {
+			level = 0
+		} else if level >= len(sparks) {
+			level = len(sparks) - 1
+		}
+		line[offset+i] = sparks[level]
+	}
+
+	return string(line)
+}
+
+func cpuSparkline(samples []fetcher.ContainerMetrics) string {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.CPU
+	}
+	return fmtSparkline(values, 0, historySize)
+}
+
+func memorySparkline(samples []fetcher.ContainerMetrics, limitMb int32) string {
+	return fmtSparkline(memoryValues(samples), float64(fetcher.Megabytes(limitMb)), historySize)
+}
+
+func diskSparkline(samples []fetcher.ContainerMetrics, limitMb int32) string {
+	return fmtSparkline(diskValues(samples), float64(fetcher.Megabytes(limitMb)), historySize)
+}
+
+func memoryValues(samples []fetcher.ContainerMetrics) []float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = float64(sample.Memory)
+	}
+	return values
+}
+
+func diskValues(samples []fetcher.ContainerMetrics) []float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = float64(sample.Disk)
+	}
+	return values
+}
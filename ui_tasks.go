@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/luan/dope/fetcher"
+)
+
+func (ui *UI) tasksToContents(tasks []*models.Task) []content {
+	ret := []content{
+		{
+			String: fmt.Sprintf(
+				"%s %s %s %s %s",
+				"[ guid     ](fg-white,bg-reverse)",
+				"[ domain      ](fg-white,bg-reverse)",
+				"[ state     ](fg-white,bg-reverse)",
+				"[ cell     ](fg-yellow,bg-reverse)",
+				"[ failure reason / result                 ](fg-white,bg-reverse)",
+			),
+		},
+	}
+
+	for _, task := range tasks {
+		outcome := escapeMarkup(truncate(strings.Replace(task.Result, "\n", " ", -1), 40))
+		if task.Failed {
+			outcome = fmt.Sprintf("[%s](fg-red)", escapeMarkup(truncate(task.FailureReason, 40)))
+		}
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					" [%-8s](fg-bold)   %-13s %s %-9s %s",
+					shortGuid(task.TaskGuid), truncate(task.Domain, 13),
+					colorizeTaskState(task), fmtCell(task.CellId), outcome,
+				),
+				task: task,
+			},
+		)
+	}
+	return ret
+}
+
+func taskDetail(task *models.Task) string {
+	outcome := fmt.Sprintf("[result:](fg-bold) %s", escapeMarkup(task.Result))
+	if task.Failed {
+		outcome = fmt.Sprintf("[failure reason:](fg-bold) [%s](fg-red)", escapeMarkup(task.FailureReason))
+	}
+
+	return fmt.Sprintf(
+		`[guid:](fg-bold) %s
+[domain:](fg-bold) %s
+[state:](fg-bold) %s
+[cell:](fg-bold) %s
+%s
+[result file:](fg-bold) %s
+[rootfs:](fg-bold) %s
+[memory limit:](fg-bold) %s
+[disk limit:](fg-bold) %s
+[cpu weight:](fg-bold) %d
+[privileged:](fg-bold) %t
+[created at:](fg-bold) %s
+[updated at:](fg-bold) %s
+[first completed at:](fg-bold) %s
+[annotation:](fg-bold) %s
+[action:](fg-bold)
+%s
+`,
+		task.TaskGuid,
+		task.Domain,
+		colorizeTaskState(task),
+		fmtCell(task.CellId),
+		outcome,
+		task.ResultFile,
+		task.RootFs,
+		fmtBytes(fetcher.Megabytes(task.MemoryMb)),
+		fmtBytes(fetcher.Megabytes(task.DiskMb)),
+		task.CpuWeight,
+		task.Privileged,
+		fmtTimestamp(task.CreatedAt),
+		fmtTimestamp(task.UpdatedAt),
+		fmtTimestamp(task.FirstCompletedAt),
+		task.Annotation,
+		fetcher.TaskCommand(task),
+	)
+}
+
+func colorizeTaskState(task *models.Task) string {
+	state := task.State.String()
+	switch task.State {
+	case models.Task_Pending:
+		return fmt.Sprintf("[%-9s](fg-white)", state)
+	case models.Task_Running:
+		return fmt.Sprintf("[%-9s](fg-green)", state)
+	case models.Task_Completed:
+		if task.Failed {
+			return fmt.Sprintf("[%-9s](fg-red)", state)
+		}
+		return fmt.Sprintf("[%-9s](fg-cyan)", state)
+	case models.Task_Resolving:
+		return fmt.Sprintf("[%-9s](fg-yellow)", state)
+	default:
+		return fmt.Sprintf("%-9s", state)
+	}
+}
+
+func fmtTimestamp(nanos int64) string {
+	if nanos == 0 {
+		return "-"
+	}
+	return time.Unix(0, nanos).Format("2006-01-02 15:04:05")
+}
+
+func shortGuid(guid string) string {
+	return truncate(guid, 8)
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
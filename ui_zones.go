@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/luan/dope/fetcher"
+)
+
+func (ui *UI) zonesToContents(zones []*fetcher.ZoneState) []content {
+	ret := []content{
+		{
+			String: fmt.Sprintf(
+				"%s %s %s %s %s %s %s %s",
+				"[ zone     ](fg-yellow,bg-reverse)",
+				"[ cells ](fg-white,bg-reverse)",
+				"[ lrps ](fg-white,bg-reverse)",
+				"[ tasks ](fg-white,bg-reverse)",
+				"[ avg cpu ](fg-magenta,bg-reverse)",
+				"[ memory](fg-cyan,bg-reverse)[/reserved  ](fg-cyan,bg-reverse)",
+				"[ disk](fg-red,bg-reverse)[/reserved    ](fg-red,bg-reverse)",
+				"[ free memory/disk  ](fg-green,bg-reverse)",
+			),
+		},
+	}
+
+	for _, zone := range zones {
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					" [%-9s](fg-yellow,fg-bold) %6d %6d %7d  [%7.1f%%](fg-magenta) [%8s](fg-cyan)[/%-8s](fg-cyan,fg-bold) [%8s](fg-red)[/%-8s](fg-red,fg-bold) [%8s/%-8s](fg-green)",
+					truncate(fmtZone(zone.Zone), 9), zone.NumCells, zone.NumLRPs, zone.NumTasks,
+					float64(100)*zone.AverageCPU(),
+					fmtBytes(zone.MemoryUsed), fmtBytes(zone.MemoryReserved),
+					fmtBytes(zone.DiskUsed), fmtBytes(zone.DiskReserved),
+					fmtCapacity(zone.MemoryFree(), zone.MemoryCapacity), fmtCapacity(zone.DiskFree(), zone.DiskCapacity),
+				),
+				zone: zone,
+			},
+		)
+	}
+
+	singleZone := ui.placement.SingleZoneLRPs(ui.filter.Apply(ui.state.LRPs))
+	if len(singleZone) == 0 {
+		return ret
+	}
+
+	ret = append(ret,
+		content{String: ""},
+		content{String: fmt.Sprintf("[ LRPs with every instance in one zone (%d) ](fg-black,bg-yellow)", len(singleZone))},
+	)
+	for _, lrp := range singleZone {
+		ret = append(ret,
+			content{
+				String: fmt.Sprintf(
+					" [%-8s](fg-bold)   %s   %s",
+					shortGuid(lrp.Desired.ProcessGuid), fmtDistribution(ui.placement.Distribution(lrp)),
+					ui.fmtAppName(lrp.Desired.ProcessGuid),
+				),
+				lrp:     lrp,
+				desired: lrp.Desired,
+			},
+		)
+	}
+	return ret
+}
+
+// fmtZone names the zone of cells whose zone is unknown.
+func fmtZone(zone string) string {
+	if zone == "" {
+		return "unknown"
+	}
+	return escapeMarkup(zone)
+}
+
+// fmtDistribution lists how many instances run in each zone, e.g. z1:2 z2:1.
+func fmtDistribution(distribution map[string]int) string {
+	zones := []string{}
+	for zone := range distribution {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	parts := []string{}
+	for _, zone := range zones {
+		parts = append(parts, fmt.Sprintf("%s:%d", fmtZone(zone), distribution[zone]))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// fmtSingleZone flags an LRP that would lose every instance to a single
+// zone going down.
+func (ui *UI) fmtSingleZone(lrp *fetcher.LRP) string {
+	if !ui.placement.SingleZone(lrp) {
+		return ""
+	}
+	return "[ single zone ](fg-black,bg-yellow)"
+}
+
+func zoneDetail(zone *fetcher.ZoneState, cells []*fetcher.CellState) string {
+	cellIds := []string{}
+	for _, cell := range cells {
+		if cell.Zone == zone.Zone {
+			cellIds = append(cellIds, cell.CellId)
+		}
+	}
+
+	return fmt.Sprintf(
+		`[zone:](fg-bold) %s
+[cells:](fg-bold) %d
+%s
+[LRPs:](fg-bold) %d
+[tasks:](fg-bold) %d
+[containers:](fg-bold) %d/%d
+[average cpu:](fg-bold) %.1f%%
+
+[memory used/reserved:](fg-bold) %s/%s
+%s
+[memory reserved/capacity:](fg-bold) %s/%s (%s free)
+%s
+
+[disk used/reserved:](fg-bold) %s/%s
+%s
+[disk reserved/capacity:](fg-bold) %s/%s (%s free)
+%s
+`,
+		fmtZone(zone.Zone),
+		zone.NumCells,
+		strings.Join(cellIds, ", "),
+		zone.NumLRPs,
+		zone.NumTasks,
+		zone.NumLRPs+zone.NumTasks, zone.ContainerCapacity,
+		float64(100)*zone.AverageCPU(),
+		fmtBytes(zone.MemoryUsed), fmtBytes(zone.MemoryReserved),
+		fmtGauge(zone.MemoryUsed, zone.MemoryReserved, 40),
+		fmtBytes(zone.MemoryReserved), fmtCapacity(zone.MemoryCapacity, zone.MemoryCapacity), fmtCapacity(zone.MemoryFree(), zone.MemoryCapacity),
+		fmtGauge(zone.MemoryReserved, zone.MemoryCapacity, 40),
+		fmtBytes(zone.DiskUsed), fmtBytes(zone.DiskReserved),
+		fmtGauge(zone.DiskUsed, zone.DiskReserved, 40),
+		fmtBytes(zone.DiskReserved), fmtCapacity(zone.DiskCapacity, zone.DiskCapacity), fmtCapacity(zone.DiskFree(), zone.DiskCapacity),
+		fmtGauge(zone.DiskReserved, zone.DiskCapacity, 40),
+	)
+}